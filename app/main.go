@@ -2,75 +2,437 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	handler "go-clean-arch/internal/adapter/handler/http"
 	"go-clean-arch/internal/adapter/repository"
 	"go-clean-arch/internal/core/usecase"
 	"go-clean-arch/internal/infraestructure/config"
+	"go-clean-arch/internal/infraestructure/health"
+	"go-clean-arch/internal/infraestructure/lifecycle"
+	"go-clean-arch/internal/infraestructure/memory"
+	"go-clean-arch/internal/infraestructure/metrics"
 	"go-clean-arch/internal/infraestructure/postgres"
+	"go-clean-arch/internal/infraestructure/redis"
+	"go-clean-arch/internal/infraestructure/sqlite"
+	"go-clean-arch/internal/infraestructure/tracing"
+	"go-clean-arch/internal/infraestructure/zaplogger"
+	"io"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"slices"
+	"strconv"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 func main() {
+	args := os.Args[1:]
 
-	//Load Envs
-	config, err := config.New()
+	//"config print [--redacted] [flags]" shows the configuration the application would run with
+	if len(args) >= 2 && args[0] == "config" && args[1] == "print" {
+		printConfig(args[2:])
+		return
+	}
+
+	//"healthcheck [flags]" exits with 0 when the running application is ready, for the Docker HEALTHCHECK
+	if len(args) >= 1 && args[0] == "healthcheck" {
+		healthcheck(args[1:])
+		return
+	}
+
+	//"secrets key" and "secrets seal" create the files of the encrypted file secret provider
+	if len(args) >= 2 && args[0] == "secrets" {
+		secretsCommand(args[1])
+		return
+	}
+
+	//Load the configuration: defaults < config file < .env < environment variables < flags
+	config, err := config.New(args...)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Fatal("Error loading environment variables: ", err)
+		log.Fatal("Error loading the configuration: ", err)
 	}
 
 	//Starting Zap Logs - (Sugar is better for performance)
-	var zp *zap.Logger
-
-	switch config.App.Env {
-	case "development":
-		zp, _ = zap.NewDevelopment()
-	default:
-		zp, _ = zap.NewProduction()
+	//Every layer logs through it, slog included. The level is atomic so LOG_LEVEL can change while running.
+	zp, level, err := zaplogger.New(config.Log)
+	if err != nil {
+		log.Fatal("Error initializing the logger: ", err)
 	}
 
 	defer zp.Sync()
 	logger := zp.Sugar()
 	logger.Info("Starting the application: ", config.App.Name, "-", config.App.Env)
 
-	ctx := context.Background()
+	//Components are appended to the lifecycle in dependency order: databases, cache, workers and the HTTP server.
+	//They are stopped in reverse order on SIGINT or SIGTERM, within APP_SHUTDOWN_TIMEOUT.
+	//The signals are handled from here on, so they also interrupt building the components, such as the database connection retries.
+	app := lifecycle.New(logger)
+	ctx := app.Context()
+
+	//abort closes the components built so far, such as the database pools, and exits when the application cannot start
+	abort := func(message string, err error) {
+		if ctx.Err() != nil {
+			logger.Info("Shutdown signal received while starting, stopping the application")
+		} else {
+			logger.Error(message, err)
+		}
+		stopErr := app.Abort(config.App.ShutdownTimeout)
+		if stopErr != nil {
+			logger.Error("Error stopping the application: ", stopErr)
+		}
+		if ctx.Err() == nil || stopErr != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	//Spans are exported as TRACING_EXPORTER says, the provider is stopped last to flush the spans of the shutdown
+	stopTracing, err := tracing.New(ctx, config.Tracing, config.App)
+	if err != nil {
+		abort("Error initializing tracing: ", err)
+	}
+	app.Append(lifecycle.Hook{
+		Name:   "tracing",
+		OnStop: stopTracing,
+	})
+
+	//Every dependency registers its readiness check, served on /readyz
+	checks := health.New(config.Health.Timeout)
 
 	//Build all external dependencies such as: Database, Message Broker Clients...
-	//In this example I will build just the database
-	database := postgres.NewDatabase(ctx, config.DB, logger)
+	//In this example I will build just the database, DB_CONNECTION=memory or sqlite runs without any external service
+	var database repository.Database
+	var pg *postgres.Postgres
+	switch config.DB.Connection {
+	case "memory":
+		database = memory.NewDatabase(logger)
+	case "sqlite":
+		sq, err := sqlite.NewDatabase(ctx, config.DB, logger)
+		if err != nil {
+			abort("Error initializing database connection: ", err)
+		}
+		database = sq
+		checks.Register("sqlite", sq.Ping)
+		app.Append(lifecycle.Hook{
+			Name: "sqlite",
+			OnStop: func(context.Context) error {
+				return sq.Close()
+			},
+		})
+	default:
+		//The first connection is retried with backoff, DB_CONNECT_RETRIES and DB_CONNECT_BACKOFF tune how long we wait for the database
+		pg, err = postgres.NewDatabase(ctx, config.DB, logger)
+		if err != nil {
+			abort("Error initializing database connection: ", err)
+		}
+		database = pg
+		metrics.RegisterPool(pg.Stats)
+		checks.Register("postgres", pg.Ping)
+		checks.Register("schema", pg.CheckSchema)
+		app.Append(lifecycle.Hook{
+			Name: "postgres",
+			OnStop: func(context.Context) error {
+				pg.Close()
+				return nil
+			},
+		})
+
+		//The binary refuses to run against a schema it does not know, migrating it first when DB_AUTO_MIGRATE=true
+		err = postgres.Migrate(ctx, config.DB, logger)
+		if err != nil {
+			abort("Error checking the database schema: ", err)
+		}
+	}
+
+	//Every repository method is timed, labeled with the adapter in use
+	database = metrics.NewDatabase(database, config.DB.Connection)
 
 	// Dependency Injection: Both this and the process above are SOLID practices,
 	// Above we isolated the database creation and now we will inject it in the repository.
 	// We are also following a Dependency Inversion Principle from SOLID, where we abstracted the infra
 	// By this way, it's easier to test our repository and we don't need to worry with the db client and other dependencies
 	// Because our client is on other section (infra), it's also easier to change the DB, we don't need to change de repo, just the infra.
-	userRepo := repository.NewRepository(database, logger)
+	var userRepo repository.UserRepository = repository.NewRepository(database, logger)
+	var transactor repository.Transactor = database
+
+	//Redis is optional, when configured user reads go through the cache before reaching the database
+	//and transactions through the cache too, so it invalidates the users they write once they commit
+	if config.Redis.Addr != "" {
+		cache := redis.NewRedis(ctx, config.Redis, logger)
+		userCache := redis.NewUserCache(userRepo, database, cache, config.Redis, logger)
+		userRepo, transactor = userCache, userCache
+		checks.RegisterOptional("redis", func(ctx context.Context) error {
+			return cache.Ping(ctx).Err()
+		})
+		app.Append(lifecycle.Hook{
+			Name: "redis",
+			OnStop: func(context.Context) error {
+				return cache.Close()
+			},
+		})
+	}
 
 	//Inject the repository into the useCase. (UseCase is responsible for the bussiness rule and don't care about external devices)
-	userUseCase := usecase.NewUserService(userRepo, logger)
+	//The database is also injected as audit repository and, through the cache when there is one, as transactor,
+	//so every mutation and its audit log are committed together.
+	//The use cases are wrapped to count their outcomes by domain error code
+	var userUseCase usecase.UserUseCase = usecase.NewUserService(userRepo, database, transactor, logger)
+	var auditUseCase usecase.AuditUseCase = usecase.NewAuditService(database, logger)
+	userUseCase = metrics.NewUserUseCase(userUseCase)
+	auditUseCase = metrics.NewAuditUseCase(auditUseCase)
 
 	//Here you can define your API, if it will be REST,gRPC or other, you just need to inject your useCase.
-	h := handler.NewHTTPHandler(userUseCase)
+	h := handler.NewHTTPHandler(userUseCase, auditUseCase, checks)
 
 	// Init router
 	router, err := handler.NewRouter(
 		config.HTTP,
 		*h,
+		logger,
 	)
 	if err != nil {
-		logger.Error("Error initializing router", "error", err)
+		abort("Error initializing router: ", err)
+	}
+
+	//Rotated database credentials are picked up every SECRETS_RELOAD_PERIOD without a restart
+	if pg != nil && config.Secrets.ReloadPeriod > 0 {
+		app.Append(lifecycle.Worker("secrets watcher", func(ctx context.Context) {
+			watchSecrets(ctx, args, config, pg, logger)
+		}))
+	}
+
+	//LOG_LEVEL, HTTP_ALLOWED_ORIGINS and the rate limits are reloaded on SIGHUP or when the configuration files change
+	app.Append(lifecycle.Worker("configuration watcher", func(ctx context.Context) {
+		watchConfig(ctx, args, config, level, router, logger)
+	}))
+
+	//Metrics are served on their own port, started before and stopped after the API so the drain can be observed
+	if config.Metrics.Port != 0 {
+		metricsServer := metrics.NewServer(net.JoinHostPort(config.Metrics.URL, strconv.Itoa(config.Metrics.Port)))
+		app.Append(lifecycle.Hook{
+			Name: "metrics server",
+			OnStart: func(context.Context) error {
+				logger.Infow("Starting the metrics server", "listen_address", metricsServer.Addr)
+				go func() {
+					err := metricsServer.ListenAndServe()
+					if err != nil && !errors.Is(err, http.ErrServerClosed) {
+						app.Fail(fmt.Errorf("metrics server: %w", err))
+					}
+				}()
+				return nil
+			},
+			OnStop: metricsServer.Shutdown,
+		})
+	}
+
+	//The admin endpoints are not authenticated, they listen on loopback unless ADMIN_URL says otherwise.
+	//GET /log/level reads the log level, a JSON PUT /log/level {"level":"debug"} changes it.
+	if config.Admin.Port != 0 {
+		admin := http.NewServeMux()
+		admin.Handle("/log/level", level)
+		adminServer := &http.Server{
+			Addr:              net.JoinHostPort(config.Admin.URL, strconv.Itoa(config.Admin.Port)),
+			Handler:           admin,
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		app.Append(lifecycle.Hook{
+			Name: "admin server",
+			OnStart: func(context.Context) error {
+				logger.Infow("Starting the admin server", "listen_address", adminServer.Addr)
+				go func() {
+					err := adminServer.ListenAndServe()
+					if err != nil && !errors.Is(err, http.ErrServerClosed) {
+						app.Fail(fmt.Errorf("admin server: %w", err))
+					}
+				}()
+				return nil
+			},
+			OnStop: adminServer.Shutdown,
+		})
+	}
+
+	// Start server, on shutdown it stops accepting requests and drains the ones in flight
+	listenAddr := fmt.Sprintf("%s:%d", config.HTTP.URL, config.HTTP.Port)
+	app.Append(lifecycle.Hook{
+		Name: "http server",
+		OnStart: func(context.Context) error {
+			logger.Infow("Starting the HTTP server", "listen_address", listenAddr)
+			go func() {
+				err := router.Serve(listenAddr)
+				if err != nil {
+					app.Fail(fmt.Errorf("http server: %w", err))
+				}
+			}()
+			return nil
+		},
+		OnStop: router.Shutdown,
+	})
+
+	//Stopped first: readiness fails for HEALTH_SHUTDOWN_DELAY so load balancers stop sending requests before the server drains
+	app.Append(lifecycle.Hook{
+		Name: "readiness",
+		OnStop: func(ctx context.Context) error {
+			checks.Shutdown()
+			select {
+			case <-time.After(config.Health.ShutdownDelay):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	err = app.Run(config.App.ShutdownTimeout)
+	if err != nil {
+		logger.Error("Error running the application: ", err)
 		os.Exit(1)
 	}
+}
 
-	// Start server
-	listenAddr := fmt.Sprintf("%s:%s", config.HTTP.URL, config.HTTP.Port)
-	logger.Info("Starting the HTTP server", "listen_address", listenAddr)
-	err = router.Serve(listenAddr)
+// printConfig prints the configuration loaded with the flags in args, masking its secrets when --redacted is given
+func printConfig(args []string) {
+	redact := false
+	var flags []string
+	for _, arg := range args {
+		if arg == "--redacted" || arg == "-redacted" {
+			redact = true
+			continue
+		}
+		flags = append(flags, arg)
+	}
+
+	config, err := config.New(flags...)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal("Error loading the configuration: ", err)
+	}
+
+	err = config.Print(os.Stdout, redact)
+	if err != nil {
+		log.Fatal("Error printing the configuration: ", err)
+	}
+}
+
+// watchConfig reloads the configuration until ctx is done, applying the new log level and HTTP settings
+func watchConfig(ctx context.Context, args []string, current *config.Container, level zap.AtomicLevel, router *handler.Router, logger *zap.SugaredLogger) {
+	watcher := config.NewWatcher(current, func() (*config.Container, error) {
+		return config.New(args...)
+	}, logger)
+
+	//The level is only set when LOG_LEVEL changed, so reloading other values keeps the one set through /log/level
+	logLevel := current.Log.Level
+	watcher.Subscribe(func(fresh *config.Container) {
+		if fresh.Log.Level != logLevel {
+			logLevel = fresh.Log.Level
+			level.SetLevel(zaplogger.Level(fresh.Log))
+		}
+	})
+	watcher.Subscribe(func(fresh *config.Container) {
+		err := router.Reload(fresh.HTTP)
+		if err != nil {
+			logger.Error("Error applying the HTTP configuration: ", err)
+		}
+	})
+
+	watcher.Watch(ctx)
+}
+
+// healthcheck requests the readiness probe of the application running with the configuration loaded
+// with the flags in args, exiting with 1 when it is not ready. Only the health settings are validated,
+// HTTP_URL and HTTP_PORT are read as they are since an invalid address fails the request anyway.
+func healthcheck(args []string) {
+	config, err := config.Load([]string{"Health"}, args...)
+	if err != nil {
+		log.Fatal("Error loading the configuration: ", err)
+	}
+
+	host := config.HTTP.URL
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+
+	client := http.Client{Timeout: config.Health.Timeout + time.Second}
+	rsp, err := client.Get("http://" + net.JoinHostPort(host, strconv.Itoa(config.HTTP.Port)) + "/readyz")
 	if err != nil {
-		logger.Error("Error starting the HTTP server", "error", err)
+		log.Fatal("Error requesting the readiness probe: ", err)
+	}
+	defer rsp.Body.Close()
+
+	io.Copy(os.Stdout, rsp.Body)
+	if rsp.StatusCode != http.StatusOK {
 		os.Exit(1)
 	}
 }
+
+// watchSecrets follows the rotation of the secrets, recycling the database pools when their credentials change
+func watchSecrets(ctx context.Context, args []string, current *config.Container, pg *postgres.Postgres, logger *zap.SugaredLogger) {
+	user, password, replicas := current.DB.User, current.DB.Password, current.DB.Replicas
+
+	load := func() (*config.Container, error) {
+		return config.New(args...)
+	}
+
+	config.WatchSecrets(ctx, current.Secrets.ReloadPeriod, current, load, func(fresh *config.Container) error {
+		if fresh.DB.User != user || fresh.DB.Password != password {
+			user, password = fresh.DB.User, fresh.DB.Password
+			pg.RotateCredentials(user, password)
+		}
+
+		//Replica DSNs carry their own credentials, the pools are replaced rather than recycled
+		if !slices.Equal(fresh.DB.Replicas, replicas) {
+			err := pg.RotateReplicas(ctx, fresh.DB.Replicas)
+			if err != nil {
+				return fmt.Errorf("connecting to the new database replicas, keeping the current ones: %w", err)
+			}
+			replicas = fresh.DB.Replicas
+		}
+
+		return nil
+	}, logger)
+}
+
+// secretsCommand prints a new SECRETS_KEY with "key", or encrypts the JSON object of secrets read
+// from the standard input with the SECRETS_KEY of the environment with "seal"
+func secretsCommand(command string) {
+	switch command {
+	case "key":
+		key := make([]byte, 32)
+		_, err := rand.Read(key)
+		if err != nil {
+			log.Fatal("Error generating the key: ", err)
+		}
+		fmt.Println(base64.StdEncoding.EncodeToString(key))
+	case "seal":
+		key, err := base64.StdEncoding.DecodeString(os.Getenv("SECRETS_KEY"))
+		if err != nil {
+			log.Fatal("SECRETS_KEY must be base64 encoded: ", err)
+		}
+
+		var secrets map[string]string
+		err = json.NewDecoder(os.Stdin).Decode(&secrets)
+		if err != nil {
+			log.Fatal("Error reading the secrets: ", err)
+		}
+
+		sealed, err := config.SealSecrets(key, secrets)
+		if err != nil {
+			log.Fatal("Error encrypting the secrets: ", err)
+		}
+		os.Stdout.Write(sealed)
+	default:
+		log.Fatal("Usage: app secrets key | app secrets seal < secrets.json > secrets.enc")
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go-clean-arch/internal/infraestructure/config"
+	"go-clean-arch/internal/infraestructure/migration"
+	"go-clean-arch/internal/infraestructure/postgres"
+	"go-clean-arch/migrations"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+const usage = `Usage: migrate [flags] <command> [arguments]
+
+Commands:
+  up             apply every pending migration
+  down           roll back every applied migration
+  status         list the migrations and whether they are applied
+  version        print the current schema version
+  goto N         migrate up or down to version N
+  steps N        apply the next N migrations, or roll back the last N when N is negative
+  force N        set the version to N without running any SQL, to recover from a dirty state
+  create NAME    scaffold a timestamped pair of up and down migrations
+
+Flags:
+`
+
+// step is a single migration to run, in the given direction
+type step struct {
+	version uint
+	up      bool
+}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "print the SQL that would be applied instead of running it")
+	dir := flag.String("dir", "migrations", "directory where create scaffolds new migrations")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	command, args := args[0], args[1:]
+
+	if command == "create" {
+		if len(args) != 1 {
+			log.Fatal("Usage: migrate create NAME")
+		}
+		err := create(*dir, args[0], time.Now())
+		if err != nil {
+			log.Fatalf("Error creating migration: %v", err)
+		}
+		return
+	}
+
+	//Only the database settings are needed, a migration job does not have to configure the HTTP server or redis
+	config, err := config.Load([]string{"DB"})
+	if err != nil {
+		log.Fatalf("Error loading environment variables: %v", err)
+	}
+
+	src, err := migration.Source(migrations.FS, ".")
+	if err != nil {
+		log.Fatalf("Error loading migrations: %v", err)
+	}
+
+	versions, err := listVersions(src)
+	if err != nil {
+		log.Fatalf("Error loading migrations: %v", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, postgres.URL(config.DB))
+	if err != nil {
+		log.Fatalf("Error connecting to the database: %v", err)
+	}
+	defer m.Close()
+
+	current, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		log.Fatalf("Error reading the schema version: %v", err)
+	}
+
+	switch command {
+	case "version":
+		printVersion(current, dirty, err)
+		return
+	case "status":
+		printVersion(current, dirty, err)
+		for _, version := range versions {
+			state := "pending"
+			if version <= current {
+				state = "applied"
+			}
+			fmt.Printf("%-16d %s\n", version, state)
+		}
+		return
+	case "force":
+		n := intArg(command, args)
+		if *dryRun {
+			fmt.Printf("Would force the version to %d\n", n)
+			return
+		}
+		err = m.Force(n)
+		if err != nil {
+			log.Fatalf("Migration error: %v", err)
+		}
+		fmt.Printf("Version forced to %d\n", n)
+		return
+	}
+
+	var plan []step
+	var run func() error
+
+	switch command {
+	case "up":
+		plan = planSteps(versions, current, len(versions))
+		run = m.Up
+	case "down":
+		plan = planSteps(versions, current, -len(versions))
+		run = m.Down
+	case "goto":
+		n := intArg(command, args)
+		if n < 0 {
+			log.Fatalf("Invalid version %d", n)
+		}
+		plan, err = planGoto(versions, current, uint(n))
+		if err != nil {
+			log.Fatal(err)
+		}
+		run = func() error { return m.Migrate(uint(n)) }
+	case "steps":
+		n := intArg(command, args)
+		plan = planSteps(versions, current, n)
+		run = func() error { return m.Steps(n) }
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if dirty {
+		log.Fatalf("The schema is dirty at version %d, fix it and run: migrate force N", current)
+	}
+
+	if *dryRun {
+		err = printPlan(src, plan)
+		if err != nil {
+			log.Fatalf("Error reading migrations: %v", err)
+		}
+		return
+	}
+
+	err = run()
+	if err != nil {
+		if errors.Is(err, migrate.ErrNoChange) {
+			fmt.Println("No migrations to apply.")
+			return
+		}
+		log.Fatalf("Migration error: %v", err)
+	}
+
+	fmt.Println("Migration completed successfully!")
+}
+
+func printVersion(version uint, dirty bool, err error) {
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+		fmt.Println("Version: none")
+	case dirty:
+		fmt.Printf("Version: %d (dirty)\n", version)
+	default:
+		fmt.Printf("Version: %d\n", version)
+	}
+}
+
+func intArg(command string, args []string) int {
+	if len(args) != 1 {
+		log.Fatalf("Usage: migrate %s N", command)
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		log.Fatalf("Invalid number %q", args[0])
+	}
+
+	return n
+}
+
+// listVersions returns every version of src in ascending order
+func listVersions(src source.Driver) ([]uint, error) {
+	version, err := src.First()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []uint{version}
+	for {
+		version, err = src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return versions, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+}
+
+// planSteps returns the next n migrations after current, or the last -n up to current when n is negative
+func planSteps(versions []uint, current uint, n int) []step {
+	var plan []step
+
+	if n >= 0 {
+		for _, version := range versions {
+			if len(plan) == n {
+				break
+			}
+			if version > current {
+				plan = append(plan, step{version, true})
+			}
+		}
+		return plan
+	}
+
+	for i := len(versions) - 1; i >= 0; i-- {
+		if len(plan) == -n {
+			break
+		}
+		if versions[i] <= current {
+			plan = append(plan, step{versions[i], false})
+		}
+	}
+
+	return plan
+}
+
+// planGoto returns the migrations between current and target
+func planGoto(versions []uint, current, target uint) ([]step, error) {
+	n := 0
+	found := false
+
+	for _, version := range versions {
+		found = found || version == target
+		switch {
+		case target > current && version > current && version <= target:
+			n++
+		case target < current && version > target && version <= current:
+			n--
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no migration found for version %d", target)
+	}
+
+	return planSteps(versions, current, n), nil
+}
+
+func printPlan(src source.Driver, plan []step) error {
+	if len(plan) == 0 {
+		fmt.Println("No migrations to apply.")
+		return nil
+	}
+
+	for _, step := range plan {
+		read := src.ReadDown
+		if step.up {
+			read = src.ReadUp
+		}
+
+		r, identifier, err := read(step.version)
+		if err != nil {
+			return err
+		}
+
+		body, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+
+		direction := "down"
+		if step.up {
+			direction = "up"
+		}
+
+		fmt.Printf("-- %d %s (%s)\n%s\n", step.version, identifier, direction, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+var nonIdentifier = regexp.MustCompile(`[^a-z0-9]+`)
+
+// create scaffolds an empty pair of up and down migrations in dir
+func create(dir, name string, now time.Time) error {
+	name = strings.Trim(nonIdentifier.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return errors.New("the migration name must contain letters or digits")
+	}
+
+	base := filepath.Join(dir, now.UTC().Format("20060102150405")+"_"+name)
+
+	for _, direction := range []string{"up", "down"} {
+		path := base + "." + direction + ".sql"
+
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return err
+		}
+		file.Close()
+
+		fmt.Println("Created", path)
+	}
+
+	return nil
+}
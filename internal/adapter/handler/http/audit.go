@@ -0,0 +1,75 @@
+package http
+
+import (
+	"go-clean-arch/internal/core/domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listAuditLogsRequest represents the request query for listing audit logs
+type listAuditLogsRequest struct {
+	Actor    string    `form:"actor" binding:"omitempty" example:"f99c44eb088fbc06a040a359491b19ac"`
+	Action   string    `form:"action" binding:"omitempty,oneof=user.create user.update user.delete" example:"user.update"`
+	TargetID string    `form:"target_id" binding:"omitempty" example:"f99c44eb088fbc06a040a359491b19ac479deca49b84508c9524eb41463a14dd"`
+	From     time.Time `form:"from" binding:"omitempty" time_format:"2006-01-02T15:04:05Z07:00" example:"1970-01-01T00:00:00Z"`
+	To       time.Time `form:"to" binding:"omitempty" time_format:"2006-01-02T15:04:05Z07:00" example:"1970-01-01T00:00:00Z"`
+	Skip     uint64    `form:"skip" binding:"omitempty" example:"0"`
+	Limit    uint64    `form:"limit" binding:"required,min=5" example:"5"`
+}
+
+// ListAuditLogs godoc
+//
+//	@Summary		List audit logs
+//	@Description	List the audit trail of user mutations with filtering and pagination
+//	@Tags			Audit
+//	@Accept			json
+//	@Produce		json,application/problem+json
+//	@Param			actor		query		string			false	"Actor"
+//	@Param			action		query		string			false	"Action"	Enums(user.create, user.update, user.delete)
+//	@Param			target_id	query		string			false	"Target ID"
+//	@Param			from		query		string			false	"Created at or after (RFC 3339)"
+//	@Param			to			query		string			false	"Created before (RFC 3339)"
+//	@Param			skip		query		uint64			false	"Skip"
+//	@Param			limit		query		uint64			true	"Limit"
+//	@Success		200			{object}	response		"Audit logs listed successfully"
+//	@Failure		400			{object}	errorResponse	"Validation error"
+//	@Failure		500			{object}	errorResponse	"Internal server error"
+//	@Failure		default		{object}	problemResponse	"Any of the errors above as application/problem+json"
+//	@Router			/v1/audit [get]
+//	@Security		BearerAuth
+func (h *Handler) ListAuditLogs(ctx *gin.Context) {
+	var req listAuditLogsRequest
+	var logsList []auditLogResponse
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		validationError(ctx, err)
+		return
+	}
+
+	filter := domain.AuditFilter{
+		Actor:    req.Actor,
+		Action:   domain.AuditAction(req.Action),
+		TargetID: req.TargetID,
+		From:     req.From,
+		To:       req.To,
+		Skip:     req.Skip,
+		Limit:    req.Limit,
+	}
+
+	logs, err := h.auditUseCase.ListAuditLogs(ctx, filter)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	for _, log := range logs {
+		logsList = append(logsList, newAuditLogResponse(&log))
+	}
+
+	total := uint64(len(logsList))
+	meta := newMeta(total, req.Limit, req.Skip)
+	rsp := toMap(meta, logsList, "audit_logs")
+
+	handleSuccess(ctx, rsp)
+}
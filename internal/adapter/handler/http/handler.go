@@ -2,18 +2,23 @@ package http
 
 import (
 	"go-clean-arch/internal/core/usecase"
+	"go-clean-arch/internal/infraestructure/health"
 
 	_ "github.com/swaggo/files"
 	_ "github.com/swaggo/gin-swagger"
 )
 
 type Handler struct {
-	userUseCase usecase.UserUseCase
+	userUseCase  usecase.UserUseCase
+	auditUseCase usecase.AuditUseCase
 	//All useCases must be injected in the handler
+	health *health.Health
 }
 
-func NewHTTPHandler(userUseCase usecase.UserUseCase) *Handler {
+func NewHTTPHandler(userUseCase usecase.UserUseCase, auditUseCase usecase.AuditUseCase, health *health.Health) *Handler {
 	return &Handler{
 		userUseCase,
+		auditUseCase,
+		health,
 	}
 }
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"go-clean-arch/internal/infraestructure/health"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// liveResponse represents the body of the liveness probe
+type liveResponse struct {
+	Status string `json:"status" example:"up"`
+}
+
+// readyResponse represents the body of the readiness probe, with the outcome of every check
+type readyResponse struct {
+	Status string          `json:"status" example:"up"`
+	Checks []checkResponse `json:"checks"`
+}
+
+// checkResponse represents the outcome of a single readiness check
+type checkResponse struct {
+	Name       string `json:"name" example:"postgres"`
+	Status     string `json:"status" example:"up"`
+	Error      string `json:"error,omitempty" example:"context deadline exceeded"`
+	Optional   bool   `json:"optional" example:"false"`
+	DurationMs int64  `json:"duration_ms" example:"3"`
+}
+
+// newReadyResponse is a helper function to create the body of the readiness probe
+func newReadyResponse(report health.Report) readyResponse {
+	checks := make([]checkResponse, 0, len(report.Checks))
+	for _, result := range report.Checks {
+		checks = append(checks, checkResponse{
+			Name:       result.Name,
+			Status:     result.Status,
+			Error:      result.Error,
+			Optional:   result.Optional,
+			DurationMs: result.Duration.Milliseconds(),
+		})
+	}
+
+	return readyResponse{
+		Status: report.Status,
+		Checks: checks,
+	}
+}
+
+// Live godoc
+//
+//	@Summary		Liveness probe
+//	@Description	Report that the process is running, without checking its dependencies
+//	@Tags			Health
+//	@Produce		json
+//	@Success		200	{object}	liveResponse	"The process is alive"
+//	@Router			/healthz [get]
+func (h *Handler) Live(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, liveResponse{Status: health.StatusUp})
+}
+
+// Ready godoc
+//
+//	@Summary		Readiness probe
+//	@Description	Run the checks of the dependencies needed to serve requests, it fails during shutdown
+//	@Tags			Health
+//	@Produce		json
+//	@Success		200	{object}	readyResponse	"Every check passed"
+//	@Failure		503	{object}	readyResponse	"A check failed or the application is shutting down"
+//	@Router			/readyz [get]
+func (h *Handler) Ready(ctx *gin.Context) {
+	report := h.health.Ready(ctx)
+
+	statusCode := http.StatusOK
+	if report.Status != health.StatusUp {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	ctx.JSON(statusCode, newReadyResponse(report))
+}
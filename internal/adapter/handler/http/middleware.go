@@ -0,0 +1,255 @@
+package http
+
+import (
+	"go-clean-arch/internal/adapter/repository"
+	"go-clean-arch/internal/core/domain"
+	"go-clean-arch/internal/core/logging"
+	"go-clean-arch/internal/infraestructure/metrics"
+	"go-clean-arch/internal/utils"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// actorHeader identifies the caller until authentication is in place, it is recorded as an unverified actor
+	actorHeader     = "X-User-ID"
+	requestIDHeader = "X-Request-ID"
+	anonymousActor  = "anonymous"
+
+	// maxRequestIDLength bounds the request IDs accepted from clients, longer ones are replaced
+	maxRequestIDLength = 128
+	// maxActorLength is the size of the audit_log.actor column, longer actors are rejected
+	maxActorLength = 255
+
+	// problemKey is the gin context key telling whether errors are rendered as problem details
+	problemKey         = "problem"
+	problemContentType = "application/problem+json"
+	problemTypePrefix  = "urn:problem-type:"
+
+	// clientIdleTimeout is how long the rate limiter remembers a client after its last request
+	clientIdleTimeout = 10 * time.Minute
+)
+
+// requestMetadata stores who issued the request, from where and its ID in the request context,
+// the ID is echoed in the X-Request-ID response header so clients can report it
+func requestMetadata() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		actor := ctx.GetHeader(actorHeader)
+		if actor == "" {
+			actor = anonymousActor
+		}
+
+		//The header is not authenticated, anyone can claim to be anyone: the actor stays unverified
+		md := domain.RequestMetadata{
+			Actor:     actor,
+			RequestID: requestID(ctx.GetHeader(requestIDHeader)),
+			ClientIP:  ctx.ClientIP(),
+		}
+		ctx.Header(requestIDHeader, md.RequestID)
+		//Every request is a read-your-writes session: after a write its reads no longer go to the replicas
+		reqCtx := repository.WithSession(ctx.Request.Context())
+		ctx.Request = ctx.Request.WithContext(domain.WithRequestMetadata(reqCtx, md))
+
+		ctx.Next()
+	}
+}
+
+// requestID returns the request ID sent by the client, or a new one when it sent none or one that
+// cannot be safely logged and echoed: too long or with characters other than letters, digits and -_.:
+func requestID(id string) string {
+	if id == "" || len(id) > maxRequestIDLength {
+		return utils.GenerateID()
+	}
+
+	for _, r := range id {
+		valid := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r)
+		if !valid {
+			return utils.GenerateID()
+		}
+	}
+
+	return id
+}
+
+// validActor rejects the requests whose actor could not be recorded in the audit log. It runs after
+// the request is logged and its error format negotiated, so the rejection is reported like any other.
+func validActor() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if utf8.RuneCountInString(domain.RequestMetadataFromContext(ctx).Actor) > maxActorLength {
+			problem := "must be at most " + strconv.Itoa(maxActorLength) + " characters"
+			handleAbort(ctx, domain.ErrInvalidRequest.
+				WithMessage(actorHeader+" "+problem).
+				WithDetails(domain.ErrorDetail{Field: actorHeader, Message: problem}))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// requestLogger hands a logger carrying the request ID, user ID and route of the request to the
+// layers handling it, through the request context, and logs the request once it is handled
+func requestLogger(logger *zap.SugaredLogger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		md := domain.RequestMetadataFromContext(ctx)
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		reqLogger := logger.With("request_id", md.RequestID, "user_id", md.Actor, "route", route)
+		ctx.Request = ctx.Request.WithContext(logging.NewContext(ctx.Request.Context(), reqLogger))
+		//Authorization, cookies and the other sensitive headers are masked by the logger
+		logging.FromContext(ctx, logger).Debugw("Request received", "headers", ctx.Request.Header)
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		log := logging.FromContext(ctx, logger).Infow
+		switch {
+		case status >= 500:
+			log = logging.FromContext(ctx, logger).Errorw
+		case status >= 400:
+			log = logging.FromContext(ctx, logger).Warnw
+		}
+
+		log("Request handled",
+			"method", ctx.Request.Method,
+			"path", ctx.Request.URL.Path,
+			"status", status,
+			"latency", time.Since(start),
+			"client_ip", md.ClientIP,
+			"size", ctx.Writer.Size(),
+		)
+	}
+}
+
+// recovery turns a panic into an internal error response, logging it with the logger of the request
+func recovery(logger *zap.SugaredLogger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(ctx *gin.Context, err any) {
+		logging.FromContext(ctx, logger).Errorw("Panic recovered", "panic", err)
+		handleAbort(ctx, domain.ErrInternal)
+	})
+}
+
+// instrument counts and times the requests by route template, so paths with IDs do not
+// create a series each, requests matching no route are labeled unmatched
+func instrument() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		metrics.HTTPRequestsInFlight.Inc()
+		defer metrics.HTTPRequestsInFlight.Dec()
+
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		method := ctx.Request.Method
+		metrics.HTTPRequests.WithLabelValues(method, route, strconv.Itoa(ctx.Writer.Status())).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// errorFormat negotiates the format of error bodies from the Accept header, problem details are
+// used when the client prefers them or, for clients accepting both, when problemByDefault is set
+func errorFormat(problemByDefault bool) gin.HandlerFunc {
+	offered := []string{binding.MIMEJSON, problemContentType}
+	if problemByDefault {
+		offered = []string{problemContentType, binding.MIMEJSON}
+	}
+
+	return func(ctx *gin.Context) {
+		ctx.Set(problemKey, ctx.NegotiateFormat(offered...) == problemContentType)
+
+		ctx.Next()
+	}
+}
+
+// limiter throttles the requests of each client with a token bucket, its limits can change while running
+type limiter struct {
+	mu      sync.Mutex
+	limit   rate.Limit
+	burst   int
+	clients map[string]*client
+	swept   time.Time
+}
+
+// client is the token bucket of a single client
+type client struct {
+	*rate.Limiter
+	seen time.Time
+}
+
+func newLimiter() *limiter {
+	return &limiter{
+		clients: map[string]*client{},
+	}
+}
+
+// setLimit allows perSecond requests per second to each client, bursts of up to burst requests, zero disables the limit
+func (l *limiter) setLimit(perSecond, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit = rate.Limit(perSecond)
+	l.burst = burst
+	for _, c := range l.clients {
+		c.SetLimit(l.limit)
+		c.SetBurst(l.burst)
+	}
+}
+
+// allow reports whether the client identified by key may make a request now
+func (l *limiter) allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit == 0 {
+		return true
+	}
+
+	now := time.Now()
+	if now.Sub(l.swept) > clientIdleTimeout {
+		for key, c := range l.clients {
+			if now.Sub(c.seen) > clientIdleTimeout {
+				delete(l.clients, key)
+			}
+		}
+		l.swept = now
+	}
+
+	c, ok := l.clients[key]
+	if !ok {
+		c = &client{Limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[key] = c
+	}
+	c.seen = now
+
+	return c.AllowN(now, 1)
+}
+
+// rateLimit rejects the requests of clients exceeding the limits of l, identified by their IP
+func rateLimit(l *limiter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !l.allow(ctx.ClientIP()) {
+			ctx.Header("Retry-After", "1")
+			handleAbort(ctx, domain.ErrTooManyRequests)
+			return
+		}
+
+		ctx.Next()
+	}
+}
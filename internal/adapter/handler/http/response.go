@@ -2,7 +2,9 @@ package http
 
 import (
 	"errors"
+	"fmt"
 	"go-clean-arch/internal/core/domain"
+	"go-clean-arch/internal/core/redact"
 	"net/http"
 	"time"
 
@@ -57,16 +59,21 @@ func newAuthResponse(token string) authResponse {
 // TODO: REMOVE FROM PAYLOAD SENSITIVE DATA (PASSWORD), ADDED ONLY FOR LOCAL TEST AND COMPILATION
 // userResponse represents a user response body
 type userResponse struct {
-	Document  string    `json:"document" binding:"required,min=11" example:"12345678911"`
+	Document  string    `json:"document" binding:"required,min=11" example:"12345678911" redact:"true"`
 	ID        string    `json:"id" example:"1"`
 	Name      string    `json:"name" example:"John Doe"`
-	Email     string    `json:"email" example:"test@example.com"`
+	Email     string    `json:"email" example:"test@example.com" redact:"email"`
 	Age       int       `json:"age" binding:"required" example:"23"`
-	Password  string    `json:"password" binding:"required,min=8" example:"12345678"`
+	Password  string    `json:"password" binding:"required,min=8" example:"12345678" redact:"true"`
 	CreatedAt time.Time `json:"created_at" example:"1970-01-01T00:00:00Z"`
 	UpdatedAt time.Time `json:"updated_at" example:"1970-01-01T00:00:00Z"`
 }
 
+// Format prints the response with its fields tagged redact masked
+func (r userResponse) Format(f fmt.State, verb rune) {
+	redact.Format(f, verb, r)
+}
+
 // newUserResponse is a helper function to create a response body for handling user data
 func newUserResponse(user *domain.User) userResponse {
 	return userResponse{
@@ -81,79 +88,209 @@ func newUserResponse(user *domain.User) userResponse {
 	}
 }
 
-// errorStatusMap is a map of defined error messages and their corresponding http status codes
-var errorStatusMap = map[error]int{
-	domain.ErrInternal:                   http.StatusInternalServerError,
-	domain.ErrDataNotFound:               http.StatusNotFound,
-	domain.ErrConflictingData:            http.StatusConflict,
-	domain.ErrInvalidCredentials:         http.StatusUnauthorized,
-	domain.ErrUnauthorized:               http.StatusUnauthorized,
-	domain.ErrEmptyAuthorizationHeader:   http.StatusUnauthorized,
-	domain.ErrInvalidAuthorizationHeader: http.StatusUnauthorized,
-	domain.ErrInvalidAuthorizationType:   http.StatusUnauthorized,
-	domain.ErrInvalidToken:               http.StatusUnauthorized,
-	domain.ErrExpiredToken:               http.StatusUnauthorized,
-	domain.ErrForbidden:                  http.StatusForbidden,
-	domain.ErrNoUpdatedData:              http.StatusBadRequest,
+// userVersionResponse represents a past version of a user response body
+type userVersionResponse struct {
+	userResponse
+	Operation string    `json:"operation" example:"UPDATE"`
+	ValidFrom time.Time `json:"valid_from" example:"1970-01-01T00:00:00Z"`
+	ValidTo   time.Time `json:"valid_to" example:"1970-01-01T00:00:00Z"`
 }
 
-// validationError sends an error response for some specific request validation error
-func validationError(ctx *gin.Context, err error) {
-	errMsgs := parseError(err)
-	errRsp := newErrorResponse(errMsgs)
-	ctx.JSON(http.StatusBadRequest, errRsp)
+// newUserVersionResponse is a helper function to create a response body for handling user version data
+func newUserVersionResponse(version *domain.UserVersion) userVersionResponse {
+	return userVersionResponse{
+		userResponse: newUserResponse(&version.User),
+		Operation:    version.Operation,
+		ValidFrom:    version.ValidFrom,
+		ValidTo:      version.ValidTo,
+	}
 }
 
-// handleError determines the status code of an error and returns a JSON response with the error message and status code
-func handleError(ctx *gin.Context, err error) {
-	statusCode, ok := errorStatusMap[err]
-	if !ok {
-		statusCode = http.StatusInternalServerError
+// auditLogResponse represents an audit log response body, actor_verified is false when
+// the actor was only claimed by the client through the X-User-ID header
+type auditLogResponse struct {
+	ID            string                        `json:"id" example:"5f2b6c0e9d4a4f5e8c1b2a3d4e5f6a7b"`
+	Actor         string                        `json:"actor" example:"anonymous"`
+	ActorVerified bool                          `json:"actor_verified" example:"false"`
+	Action        string                        `json:"action" example:"user.update"`
+	TargetType    string                        `json:"target_type" example:"user"`
+	TargetID      string                        `json:"target_id" example:"f99c44eb088fbc06a040a359491b19ac479deca49b84508c9524eb41463a14dd"`
+	Changes       map[string]domain.AuditChange `json:"changes"`
+	RequestID     string                        `json:"request_id,omitempty" example:"3f1c9a7e-0b5d-4c8e-9f2a-6d7e8f9a0b1c"`
+	ClientIP      string                        `json:"client_ip" example:"127.0.0.1"`
+	CreatedAt     time.Time                     `json:"created_at" example:"1970-01-01T00:00:00Z"`
+}
+
+// newAuditLogResponse is a helper function to create a response body for handling audit log data
+func newAuditLogResponse(log *domain.AuditLog) auditLogResponse {
+	return auditLogResponse{
+		ID:            log.ID,
+		Actor:         log.Actor,
+		ActorVerified: log.ActorVerified,
+		Action:        string(log.Action),
+		TargetType:    log.TargetType,
+		TargetID:      log.TargetID,
+		Changes:       log.Changes,
+		RequestID:     log.RequestID,
+		ClientIP:      log.ClientIP,
+		CreatedAt:     log.CreatedAt,
 	}
+}
 
-	errMsg := parseError(err)
-	errRsp := newErrorResponse(errMsg)
-	ctx.JSON(statusCode, errRsp)
+// errorStatusMap is a map of domain error codes and their corresponding http status codes
+var errorStatusMap = map[domain.ErrorCode]int{
+	domain.CodeInternal:                   http.StatusInternalServerError,
+	domain.CodeDataNotFound:               http.StatusNotFound,
+	domain.CodeConflictingData:            http.StatusConflict,
+	domain.CodeInvalidData:                http.StatusUnprocessableEntity,
+	domain.CodeInvalidRequest:             http.StatusBadRequest,
+	domain.CodeConcurrentUpdate:           http.StatusConflict,
+	domain.CodeUnavailable:                http.StatusServiceUnavailable,
+	domain.CodeTooManyRequests:            http.StatusTooManyRequests,
+	domain.CodeInvalidCredentials:         http.StatusUnauthorized,
+	domain.CodeUnauthorized:               http.StatusUnauthorized,
+	domain.CodeEmptyAuthorizationHeader:   http.StatusUnauthorized,
+	domain.CodeInvalidAuthorizationHeader: http.StatusUnauthorized,
+	domain.CodeInvalidAuthorizationType:   http.StatusUnauthorized,
+	domain.CodeInvalidToken:               http.StatusUnauthorized,
+	domain.CodeExpiredToken:               http.StatusUnauthorized,
+	domain.CodeForbidden:                  http.StatusForbidden,
+	domain.CodeNoUpdatedData:              http.StatusBadRequest,
 }
 
-// handleAbort sends an error response and aborts the request with the specified status code and error message
-func handleAbort(ctx *gin.Context, err error) {
-	statusCode, ok := errorStatusMap[err]
+// errorStatus returns the domain error err wraps and its status code,
+// errors outside of the domain are reported as ErrInternal
+func errorStatus(err error) (int, *domain.Error) {
+	domainErr := domain.AsError(err)
+
+	statusCode, ok := errorStatusMap[domainErr.Code]
 	if !ok {
 		statusCode = http.StatusInternalServerError
 	}
 
-	errMsg := parseError(err)
-	errRsp := newErrorResponse(errMsg)
-	ctx.AbortWithStatusJSON(statusCode, errRsp)
+	return statusCode, domainErr
 }
 
-// parseError parses error messages from the error object and returns a slice of error messages
-func parseError(err error) []string {
+// validationError sends an error response for a request that cannot be bound or validated,
+// with a message per field in the language of the request
+func validationError(ctx *gin.Context, err error) {
+	domainErr := domain.ErrInvalidRequest.Wrap(err)
 	var errMsgs []string
+	trans := requestTranslator(ctx)
 
-	if errors.As(err, &validator.ValidationErrors{}) {
-		for _, err := range err.(validator.ValidationErrors) {
-			errMsgs = append(errMsgs, err.Error())
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		for _, fieldErr := range validationErrs {
+			errMsg := fieldErr.Translate(trans)
+			errMsgs = append(errMsgs, errMsg)
+			domainErr = domainErr.WithDetails(domain.ErrorDetail{
+				Field:   fieldErr.Field(),
+				Message: errMsg,
+			})
 		}
 	} else {
-		errMsgs = append(errMsgs, err.Error())
+		errMsg, field := bindingMessage(trans, err)
+		errMsgs = append(errMsgs, errMsg)
+		if field != "" {
+			domainErr = domainErr.WithDetails(domain.ErrorDetail{
+				Field:   field,
+				Message: errMsg,
+			})
+		}
 	}
 
-	return errMsgs
+	errRsp := errorBody(ctx, http.StatusBadRequest, domainErr, errMsgs)
+	ctx.JSON(http.StatusBadRequest, errRsp)
+}
+
+// handleError determines the status code of an error and returns a JSON response with the error message and status code
+func handleError(ctx *gin.Context, err error) {
+	statusCode, domainErr := errorStatus(err)
+
+	errRsp := errorBody(ctx, statusCode, domainErr, []string{domainErr.Message})
+	ctx.JSON(statusCode, errRsp)
+}
+
+// handleAbort sends an error response and aborts the request with the specified status code and error message
+func handleAbort(ctx *gin.Context, err error) {
+	statusCode, domainErr := errorStatus(err)
+
+	errRsp := errorBody(ctx, statusCode, domainErr, []string{domainErr.Message})
+	ctx.AbortWithStatusJSON(statusCode, errRsp)
 }
 
 // errorResponse represents an error response body format
 type errorResponse struct {
-	Success  bool     `json:"success" example:"false"`
-	Messages []string `json:"messages" example:"Error message 1, Error message 2"`
+	Success   bool                  `json:"success" example:"false"`
+	Code      string                `json:"code" example:"conflicting_data"`
+	Messages  []string              `json:"messages" example:"Error message 1, Error message 2"`
+	Details   []errorDetailResponse `json:"details,omitempty"`
+	RequestID string                `json:"request_id,omitempty" example:"3f1c9a7e0b5d4c8e9f2a6d7e8f9a0b1c"`
+}
+
+// errorDetailResponse represents what is wrong with a single field of the request
+type errorDetailResponse struct {
+	Field   string `json:"field" example:"email"`
+	Message string `json:"message" example:"already in use"`
 }
 
 // newErrorResponse is a helper function to create an error response body
-func newErrorResponse(errMsgs []string) errorResponse {
+func newErrorResponse(err *domain.Error, errMsgs []string) errorResponse {
+	var details []errorDetailResponse
+	for _, detail := range err.Details {
+		details = append(details, errorDetailResponse{
+			Field:   detail.Field,
+			Message: detail.Message,
+		})
+	}
+
 	return errorResponse{
 		Success:  false,
+		Code:     string(err.Code),
 		Messages: errMsgs,
+		Details:  details,
+	}
+}
+
+// errorBody returns the error response body in the format negotiated for the request,
+// setting the content type of the problem details format when it is the one chosen
+func errorBody(ctx *gin.Context, statusCode int, err *domain.Error, errMsgs []string) any {
+	if !ctx.GetBool(problemKey) {
+		rsp := newErrorResponse(err, errMsgs)
+		rsp.RequestID = domain.RequestIDFromContext(ctx)
+		return rsp
+	}
+
+	ctx.Header("Content-Type", problemContentType)
+	return newProblemResponse(ctx, statusCode, err)
+}
+
+// problemResponse represents an RFC 9457 problem details body, sent instead of errorResponse
+// when the request accepts application/problem+json or the server uses it by default
+type problemResponse struct {
+	Type      string                `json:"type" example:"urn:problem-type:conflicting_data"`
+	Title     string                `json:"title" example:"Conflict"`
+	Status    int                   `json:"status" example:"409"`
+	Detail    string                `json:"detail" example:"email already in use"`
+	Instance  string                `json:"instance" example:"/v1/user"`
+	Code      string                `json:"code" example:"conflicting_data"`
+	Errors    []errorDetailResponse `json:"errors,omitempty"`
+	RequestID string                `json:"request_id,omitempty" example:"3f1c9a7e0b5d4c8e9f2a6d7e8f9a0b1c"`
+}
+
+// newProblemResponse is a helper function to create a problem details body
+func newProblemResponse(ctx *gin.Context, statusCode int, err *domain.Error) problemResponse {
+	rsp := newErrorResponse(err, nil)
+
+	return problemResponse{
+		Type:      problemTypePrefix + rsp.Code,
+		Title:     http.StatusText(statusCode),
+		Status:    statusCode,
+		Detail:    err.Message,
+		Instance:  ctx.Request.URL.Path,
+		Code:      rsp.Code,
+		Errors:    rsp.Details,
+		RequestID: domain.RequestIDFromContext(ctx),
 	}
 }
 
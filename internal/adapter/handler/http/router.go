@@ -1,36 +1,68 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"go-clean-arch/internal/infraestructure/config"
-	"log/slog"
-	"strings"
+	"net/http"
+	"sync/atomic"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	sloggin "github.com/samber/slog-gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.uber.org/zap"
 )
 
 type Router struct {
 	*gin.Engine
+	cors    *atomic.Pointer[gin.HandlerFunc]
+	limiter *limiter
+	server  *http.Server
 }
 
 func NewRouter(
 	config *config.HTTP,
 	handler Handler,
+	logger *zap.SugaredLogger,
 ) (*Router, error) {
 	if config.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	ginConfig := cors.DefaultConfig()
-	allowedOrigins := config.AllowedOrigins
-	originsList := strings.Split(allowedOrigins, ",")
-	ginConfig.AllowOrigins = originsList
+	err := registerValidation()
+	if err != nil {
+		return nil, err
+	}
 
 	router := gin.New()
-	router.Use(sloggin.New(slog.Default()), gin.Recovery(), cors.New(ginConfig))
+	r := &Router{
+		router,
+		&atomic.Pointer[gin.HandlerFunc]{},
+		newLimiter(),
+		&http.Server{Handler: router, ReadHeaderTimeout: config.ReadHeaderTimeout},
+	}
+
+	//CORS origins and rate limits are reloadable, see Reload
+	err = r.Reload(config)
+	if err != nil {
+		return nil, err
+	}
+
+	router.ContextWithFallback = true
+
+	//The client IP keys the rate limit and is audited, it is only taken from the forwarding headers of trusted proxies
+	err = router.SetTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	//Probes are registered before the middlewares: they are neither logged nor rate limited
+	router.GET("/healthz", recovery(logger), handler.Live)
+	router.GET("/readyz", recovery(logger), handler.Ready)
+
+	router.Use(otelgin.Middleware(config.Name), instrument(), requestMetadata(), requestLogger(logger), recovery(logger), r.corsHandler(), errorFormat(config.ErrorFormat == "problem"), language(), validActor(), rateLimit(r.limiter))
 
 	router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
@@ -41,16 +73,58 @@ func NewRouter(
 			user.POST("", handler.Register)
 			user.GET("", handler.ListUsers)
 			user.GET("/:id", handler.GetUser)
+			user.GET("/:id/history", handler.GetUserHistory)
 			user.PUT("/", handler.UpdateUser)
 			user.DELETE("/:id", handler.DeleteUser)
 		}
+		audit := v1.Group("/audit")
+		{
+			audit.GET("", handler.ListAuditLogs)
+		}
 	}
 
-	return &Router{
-		router,
-	}, nil
+	return r, nil
 }
 
+// Reload applies the allowed origins and rate limits of config to the running router
+func (r *Router) Reload(config *config.HTTP) error {
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowOrigins = config.AllowedOrigins
+	//Browsers may send their own request IDs and read the one of the response
+	corsConfig.AddAllowHeaders(requestIDHeader)
+	corsConfig.AddExposeHeaders(requestIDHeader)
+	err := corsConfig.Validate()
+	if err != nil {
+		return err
+	}
+
+	handler := cors.New(corsConfig)
+	r.cors.Store(&handler)
+	r.limiter.setLimit(config.RateLimit, config.RateBurst)
+
+	return nil
+}
+
+// corsHandler runs the CORS middleware of the current configuration
+func (r *Router) corsHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		(*r.cors.Load())(ctx)
+	}
+}
+
+// Serve accepts requests on listenAddr until Shutdown is called
 func (r *Router) Serve(listenAddr string) error {
-	return r.Run(listenAddr)
+	r.server.Addr = listenAddr
+
+	err := r.server.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// Shutdown stops accepting requests and waits for the ones in flight until ctx is done
+func (r *Router) Shutdown(ctx context.Context) error {
+	return r.server.Shutdown(ctx)
 }
@@ -1,17 +1,25 @@
 package http
 
 import (
+	"fmt"
 	"go-clean-arch/internal/core/domain"
+	"go-clean-arch/internal/core/redact"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type registerRequest struct {
-	Document string `json:"document" binding:"required,min=11" example:"12345678911"`
-	Name     string `json:"name" binding:"required" example:"John Doe"`
-	Email    string `json:"email" binding:"required" example:"murilo@gmail.com"`
-	Age      int    `json:"age" binding:"required" example:"23"`
-	Password string `json:"password" binding:"required,min=8" example:"12345678"`
+	Document string `json:"document" binding:"required,min=11,max=20" example:"12345678911" redact:"true"`
+	Name     string `json:"name" binding:"required,max=255" example:"John Doe"`
+	Email    string `json:"email" binding:"required,max=254" example:"murilo@gmail.com" redact:"email"`
+	Age      int    `json:"age" binding:"required,min=0,max=150" example:"23"`
+	Password string `json:"password" binding:"required,min=8,maxbytes=72" example:"12345678" redact:"true"`
+}
+
+// Format prints the request with its fields tagged redact masked
+func (r registerRequest) Format(f fmt.State, verb rune) {
+	redact.Format(f, verb, r)
 }
 
 // Register godoc
@@ -20,7 +28,7 @@ type registerRequest struct {
 //	@Description	create a new user account with default role "customer"
 //	@Tags			User
 //	@Accept			json
-//	@Produce		json
+//	@Produce		json,application/problem+json
 //	@Param			registerRequest	body		registerRequest	true	"Register request"
 //	@Success		201				{object}	userResponse	"User created"
 //	@Failure		400				{object}	errorResponse	"Validation error"
@@ -28,6 +36,7 @@ type registerRequest struct {
 //	@Failure		404				{object}	errorResponse	"Data not found error"
 //	@Failure		409				{object}	errorResponse	"Data conflict error"
 //	@Failure		500				{object}	errorResponse	"Internal server error"
+//	@Failure		default			{object}	problemResponse	"Any of the errors above as application/problem+json"
 //	@Router			/v1/user [post]
 func (h *Handler) Register(ctx *gin.Context) {
 	var req registerRequest
@@ -67,12 +76,13 @@ type listUsersRequest struct {
 //	@Description	List users with pagination
 //	@Tags			User
 //	@Accept			json
-//	@Produce		json
+//	@Produce		json,application/problem+json
 //	@Param			skip	query		uint64			false	"Skip"
 //	@Param			limit	query		uint64			true	"Limit"
 //	@Success		200		{object}	response		"Users listed successfully"
 //	@Failure		400		{object}	errorResponse	"Validation error"
 //	@Failure		500		{object}	errorResponse	"Internal server error"
+//	@Failure		default	{object}	problemResponse	"Any of the errors above as application/problem+json"
 //	@Router			/v1/user [get]
 //	@Security		BearerAuth
 func (h *Handler) ListUsers(ctx *gin.Context) {
@@ -113,28 +123,49 @@ type getUserRequest struct {
 	ID string `uri:"id" binding:"required,min=1" example:"f99c44eb088fbc06a040a359491b19ac479deca49b84508c9524eb41463a14dd"`
 }
 
+// getUserAsOfRequest represents the optional point in time for getting a user
+type getUserAsOfRequest struct {
+	AsOf time.Time `form:"as_of" binding:"omitempty" time_format:"2006-01-02T15:04:05Z07:00" example:"1970-01-01T00:00:00Z"`
+}
+
 // GetUser godoc
 //
 //	@Summary		Get a user
-//	@Description	Get a user by id
+//	@Description	Get a user by id, optionally as it was at a point in time
 //	@Tags			User
 //	@Accept			json
-//	@Produce		json
-//	@Param			id	path		string			true	"User ID"
-//	@Success		200	{object}	response		"User displayed successfully"
-//	@Failure		400	{object}	errorResponse	"Validation error"
-//	@Failure		404	{object}	errorResponse	"Data not found error"
-//	@Failure		500	{object}	errorResponse	"Internal server error"
+//	@Produce		json,application/problem+json
+//	@Param			id		path		string			true	"User ID"
+//	@Param			as_of	query		string			false	"Point in time (RFC 3339)"
+//	@Success		200		{object}	response		"User displayed successfully"
+//	@Failure		400		{object}	errorResponse	"Validation error"
+//	@Failure		404		{object}	errorResponse	"Data not found error"
+//	@Failure		500		{object}	errorResponse	"Internal server error"
+//	@Failure		default	{object}	problemResponse	"Any of the errors above as application/problem+json"
 //	@Router			/v1/user/{id} [get]
 //	@Security		BearerAuth
 func (h *Handler) GetUser(ctx *gin.Context) {
 	var req getUserRequest
+	var asOfReq getUserAsOfRequest
+
 	if err := ctx.ShouldBindUri(&req); err != nil {
 		validationError(ctx, err)
 		return
 	}
 
-	user, err := h.userUseCase.GetUser(ctx, req.ID)
+	if err := ctx.ShouldBindQuery(&asOfReq); err != nil {
+		validationError(ctx, err)
+		return
+	}
+
+	var user *domain.User
+	var err error
+
+	if asOfReq.AsOf.IsZero() {
+		user, err = h.userUseCase.GetUser(ctx, req.ID)
+	} else {
+		user, err = h.userUseCase.GetUserAsOf(ctx, req.ID, asOfReq.AsOf)
+	}
 	if err != nil {
 		handleError(ctx, err)
 		return
@@ -145,14 +176,73 @@ func (h *Handler) GetUser(ctx *gin.Context) {
 	handleSuccess(ctx, rsp)
 }
 
+// getUserHistoryRequest represents the request query for listing the versions of a user
+type getUserHistoryRequest struct {
+	Skip  uint64 `form:"skip" binding:"omitempty" example:"0"`
+	Limit uint64 `form:"limit" binding:"required,min=5" example:"5"`
+}
+
+// GetUserHistory godoc
+//
+//	@Summary		Get the history of a user
+//	@Description	List the past versions of a user, most recent first
+//	@Tags			User
+//	@Accept			json
+//	@Produce		json,application/problem+json
+//	@Param			id		path		string			true	"User ID"
+//	@Param			skip	query		uint64			false	"Skip"
+//	@Param			limit	query		uint64			true	"Limit"
+//	@Success		200		{object}	response		"User history listed successfully"
+//	@Failure		400		{object}	errorResponse	"Validation error"
+//	@Failure		500		{object}	errorResponse	"Internal server error"
+//	@Failure		default	{object}	problemResponse	"Any of the errors above as application/problem+json"
+//	@Router			/v1/user/{id}/history [get]
+//	@Security		BearerAuth
+func (h *Handler) GetUserHistory(ctx *gin.Context) {
+	var uriReq getUserRequest
+	var req getUserHistoryRequest
+	var versionsList []userVersionResponse
+
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		validationError(ctx, err)
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		validationError(ctx, err)
+		return
+	}
+
+	versions, err := h.userUseCase.GetUserHistory(ctx, uriReq.ID, req.Skip, req.Limit)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	for _, version := range versions {
+		versionsList = append(versionsList, newUserVersionResponse(&version))
+	}
+
+	total := uint64(len(versionsList))
+	meta := newMeta(total, req.Limit, req.Skip)
+	rsp := toMap(meta, versionsList, "history")
+
+	handleSuccess(ctx, rsp)
+}
+
 // updateUserRequest represents the request body to update a user
 type updateUserRequest struct {
-	ID       string `json:"id" binding:"required" example:"f99c44eb088fbc06a040a359491b19ac479deca49b84508c9524eb41463a14dd"`
-	Document string `json:"document" binding:"required" example:"12345678911"`
-	Name     string `json:"name" binding:"required" example:"John Doe"`
-	Email    string `json:"email" binding:"required" example:"murilo@gmail.com"`
-	Age      int    `json:"age" binding:"required" example:"23"`
-	Password string `json:"password" binding:"required" example:"12345678"`
+	ID       string `json:"id" binding:"required,max=64" example:"f99c44eb088fbc06a040a359491b19ac479deca49b84508c9524eb41463a14dd"`
+	Document string `json:"document" binding:"required,max=20" example:"12345678911" redact:"true"`
+	Name     string `json:"name" binding:"required,max=255" example:"John Doe"`
+	Email    string `json:"email" binding:"required,max=254" example:"murilo@gmail.com" redact:"email"`
+	Age      int    `json:"age" binding:"required,min=0,max=150" example:"23"`
+	Password string `json:"password" binding:"required,min=8,maxbytes=72" example:"12345678" redact:"true"`
+}
+
+// Format prints the request with its fields tagged redact masked
+func (r updateUserRequest) Format(f fmt.State, verb rune) {
+	redact.Format(f, verb, r)
 }
 
 // UpdateUser godoc
@@ -161,12 +251,13 @@ type updateUserRequest struct {
 //	@Description	Update a user by id
 //	@Tags			User
 //	@Accept			json
-//	@Produce		json
+//	@Produce		json,application/problem+json
 //	@Param			updateUserRequest	body		updateUserRequest	true	"Update user request"
 //	@Success		200	{object}	response		"User updated successfully"
 //	@Failure		400	{object}	errorResponse	"Validation error"
 //	@Failure		404	{object}	errorResponse	"Data not found error"
 //	@Failure		500	{object}	errorResponse	"Internal server error"
+//	@Failure		default	{object}	problemResponse	"Any of the errors above as application/problem+json"
 //	@Router			/v1/user [put]
 //	@Security		BearerAuth
 func (h *Handler) UpdateUser(ctx *gin.Context) {
@@ -208,12 +299,13 @@ type deleteUserRequest struct {
 //	@Description	Delete a user by id
 //	@Tags			User
 //	@Accept			json
-//	@Produce		json
+//	@Produce		json,application/problem+json
 //	@Param			id	path		string			true	"User ID"
 //	@Success		200	{object}	response		"User deleted successfully"
 //	@Failure		400	{object}	errorResponse	"Validation error"
 //	@Failure		404	{object}	errorResponse	"Data not found error"
 //	@Failure		500	{object}	errorResponse	"Internal server error"
+//	@Failure		default	{object}	problemResponse	"Any of the errors above as application/problem+json"
 //	@Router			/v1/user/{id} [delete]
 //	@Security		BearerAuth
 func (h *Handler) DeleteUser(ctx *gin.Context) {
@@ -0,0 +1,212 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/pt_BR"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	ptBRTranslations "github.com/go-playground/validator/v10/translations/pt_BR"
+)
+
+// translatorKey is the gin context key of the translator negotiated for the request
+const translatorKey = "translator"
+
+// universalTranslator holds the languages validation messages are available in, English is the fallback
+var universalTranslator = ut.New(en.New(), en.New(), pt_BR.New())
+
+// messages are the messages of the validations added to the validator and of the requests
+// that cannot be bound, in every supported language
+var messages = map[string]map[string]string{
+	"en": {
+		"maxbytes":  "{0} must be at most {1} bytes long",
+		"malformed": "the request body is not valid JSON",
+		"type":      "{0} has an invalid type",
+		"invalid":   "the request is invalid",
+	},
+	"pt_BR": {
+		"maxbytes":  "{0} deve ter no máximo {1} bytes",
+		"malformed": "o corpo da requisição não é um JSON válido",
+		"type":      "{0} tem um tipo inválido",
+		"invalid":   "a requisição é inválida",
+	},
+}
+
+// registerValidation names fields after their JSON, form or URI name in validation errors, adds
+// the validations of the API and registers the messages of every supported language in the
+// validator used by gin
+func registerValidation() error {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected validator engine")
+	}
+
+	validate.RegisterTagNameFunc(fieldName)
+
+	err := validate.RegisterValidation("maxbytes", maxBytes)
+	if err != nil {
+		return err
+	}
+
+	enTrans, _ := universalTranslator.GetTranslator("en")
+	err = enTranslations.RegisterDefaultTranslations(validate, enTrans)
+	if err != nil {
+		return err
+	}
+
+	ptBRTrans, _ := universalTranslator.GetTranslator("pt_BR")
+	err = ptBRTranslations.RegisterDefaultTranslations(validate, ptBRTrans)
+	if err != nil {
+		return err
+	}
+
+	for locale, localeMessages := range messages {
+		trans, _ := universalTranslator.GetTranslator(locale)
+		for key, message := range localeMessages {
+			err = trans.Add(key, message, false)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = validate.RegisterTranslation("maxbytes", trans, func(ut.Translator) error {
+			return nil
+		}, func(trans ut.Translator, fe validator.FieldError) string {
+			msg, _ := trans.T("maxbytes", fe.Field(), fe.Param())
+			return msg
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bindingMessage returns the message, in the language of trans, of a request that cannot be
+// bound, and the field it is about when it is known. The error itself, such as a JSON decoding
+// or a number parsing error, is not shown to clients.
+func bindingMessage(trans ut.Translator, err error) (string, string) {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		msg, _ := trans.T("type", typeErr.Field)
+		return msg, typeErr.Field
+	case errors.As(err, &syntaxErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		msg, _ := trans.T("malformed")
+		return msg, ""
+	}
+
+	msg, _ := trans.T("invalid")
+	return msg, ""
+}
+
+// maxBytes validates that a string is at most as many bytes long as its parameter, unlike max
+// counting characters. It bounds the passwords, as bcrypt rejects those longer than 72 bytes.
+func maxBytes(fl validator.FieldLevel) bool {
+	limit, err := strconv.Atoi(fl.Param())
+	if err != nil {
+		panic("maxbytes: invalid parameter " + fl.Param())
+	}
+
+	return len(fl.Field().String()) <= limit
+}
+
+// fieldName returns the name clients know field by
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// language picks the translator of the request from its Accept-Language header
+func language() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set(translatorKey, findTranslator(ctx.GetHeader("Accept-Language")))
+
+		ctx.Next()
+	}
+}
+
+// languageLocales maps a language without region to the supported locale used for it
+var languageLocales = map[string]string{
+	"en": "en",
+	"pt": "pt_BR",
+}
+
+// findTranslator returns the translator of the preferred supported language of an
+// Accept-Language header, or the English one when there is none
+func findTranslator(acceptLanguage string) ut.Translator {
+	type language struct {
+		locale  string
+		quality float64
+	}
+
+	var languages []language
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+
+		languages = append(languages, language{strings.ReplaceAll(tag, "-", "_"), quality})
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	var locales []string
+	for _, l := range languages {
+		base, _, _ := strings.Cut(l.locale, "_")
+		locales = append(locales, l.locale)
+		if locale, ok := languageLocales[strings.ToLower(base)]; ok {
+			locales = append(locales, locale)
+		}
+	}
+
+	trans, _ := universalTranslator.FindTranslator(locales...)
+	return trans
+}
+
+// requestTranslator returns the translator negotiated for the request
+func requestTranslator(ctx *gin.Context) ut.Translator {
+	if trans, ok := ctx.Value(translatorKey).(ut.Translator); ok {
+		return trans
+	}
+
+	trans, _ := universalTranslator.GetTranslator("en")
+	return trans
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"go-clean-arch/internal/core/domain"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -20,21 +21,33 @@ func NewRepository(db UserRepository, logger *zap.SugaredLogger) *Repository {
 }
 
 func (r *Repository) Save(ctx context.Context, user *domain.User) error {
-	return r.db.Save(context.TODO(), user)
+	return r.db.Save(ctx, user)
 }
 
 func (r *Repository) List(ctx context.Context, skip, limit uint64) ([]domain.User, error) {
-	return r.db.List(context.TODO(), skip, limit)
+	return r.db.List(ctx, skip, limit)
 }
 
 func (r *Repository) Get(ctx context.Context, id string) (*domain.User, error) {
-	return r.db.Get(context.TODO(), id)
+	return r.db.Get(ctx, id)
+}
+
+func (r *Repository) GetForUpdate(ctx context.Context, id string) (*domain.User, error) {
+	return r.db.GetForUpdate(ctx, id)
 }
 
 func (r *Repository) Update(ctx context.Context, user *domain.User) error {
-	return r.db.Update(context.TODO(), user)
+	return r.db.Update(ctx, user)
 }
 
 func (r *Repository) Delete(ctx context.Context, id string) error {
-	return r.db.Delete(context.TODO(), id)
+	return r.db.Delete(ctx, id)
+}
+
+func (r *Repository) History(ctx context.Context, id string, skip, limit uint64) ([]domain.UserVersion, error) {
+	return r.db.History(ctx, id, skip, limit)
+}
+
+func (r *Repository) GetAsOf(ctx context.Context, id string, asOf time.Time) (*domain.User, error) {
+	return r.db.GetAsOf(ctx, id, asOf)
 }
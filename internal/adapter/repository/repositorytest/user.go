@@ -0,0 +1,591 @@
+// Package repositorytest provides the contract every repository implementation must satisfy.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-clean-arch/internal/adapter/repository"
+	"go-clean-arch/internal/core/domain"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// UserRepositoryFactory returns an empty repository, isolated from the ones returned to other tests
+type UserRepositoryFactory func(t *testing.T) repository.UserRepository
+
+// TestUserRepository runs the UserRepository contract against the repositories built by newRepo
+func TestUserRepository(t *testing.T, newRepo UserRepositoryFactory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repo repository.UserRepository)
+	}{
+		{"SaveAndGet", testSaveAndGet},
+		{"SaveConflicts", testSaveConflicts},
+		{"InvalidData", testInvalidData},
+		{"GetNotFound", testGetNotFound},
+		{"ListOrderAndPagination", testListOrderAndPagination},
+		{"Update", testUpdate},
+		{"UpdateConflict", testUpdateConflict},
+		{"UpdateNotFound", testUpdateNotFound},
+		{"Delete", testDelete},
+		{"DeleteNotFound", testDeleteNotFound},
+		{"HistoryAndGetAsOf", testHistoryAndGetAsOf},
+		{"TransactionRollback", testTransactionRollback},
+		{"GetForUpdate", testGetForUpdate},
+		{"ConcurrentSaves", testConcurrentSaves},
+		{"ConcurrentConflictingSaves", testConcurrentConflictingSaves},
+		{"ConcurrentUpdates", testConcurrentUpdates},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepo(t))
+		})
+	}
+}
+
+// newUser returns a valid user whose unique columns are derived from n
+func newUser(n int) *domain.User {
+	return &domain.User{
+		ID:       fmt.Sprintf("user-%03d", n),
+		Document: fmt.Sprintf("%011d", n),
+		Name:     fmt.Sprintf("User %d", n),
+		Email:    fmt.Sprintf("user%d@example.com", n),
+		Age:      20 + n%50,
+		Password: fmt.Sprintf("hashed-password-%d", n),
+	}
+}
+
+func mustSave(t *testing.T, repo repository.UserRepository, user *domain.User) {
+	t.Helper()
+
+	if err := repo.Save(context.Background(), user); err != nil {
+		t.Fatalf("Save(%s): unexpected error: %v", user.ID, err)
+	}
+}
+
+func assertSameUser(t *testing.T, got, want *domain.User) {
+	t.Helper()
+
+	if got.ID != want.ID || got.Document != want.Document || got.Name != want.Name ||
+		got.Email != want.Email || got.Age != want.Age || got.Password != want.Password {
+		t.Fatalf("got user %+v, want %+v", *got, *want)
+	}
+}
+
+func assertErr(t *testing.T, got, want error) {
+	t.Helper()
+
+	if !errors.Is(got, want) {
+		t.Fatalf("got error %v, want %v", got, want)
+	}
+}
+
+func testSaveAndGet(t *testing.T, repo repository.UserRepository) {
+	ctx := context.Background()
+	user := newUser(1)
+
+	mustSave(t, repo, user)
+
+	if user.CreatedAt.IsZero() || user.UpdatedAt.IsZero() {
+		t.Fatalf("Save did not fill the timestamps: %+v", *user)
+	}
+
+	got, err := repo.Get(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Get: unexpected error: %v", err)
+	}
+
+	assertSameUser(t, got, newUser(1))
+
+	if !got.CreatedAt.Equal(user.CreatedAt) {
+		t.Fatalf("got created_at %v, want %v", got.CreatedAt, user.CreatedAt)
+	}
+}
+
+func testSaveConflicts(t *testing.T, repo repository.UserRepository) {
+	ctx := context.Background()
+	mustSave(t, repo, newUser(1))
+
+	sameID := newUser(2)
+	sameID.ID = newUser(1).ID
+
+	sameDocument := newUser(3)
+	sameDocument.Document = newUser(1).Document
+
+	sameEmail := newUser(4)
+	sameEmail.Email = newUser(1).Email
+
+	sameEmailOtherCase := newUser(5)
+	sameEmailOtherCase.Email = strings.ToUpper(newUser(1).Email)
+
+	for name, user := range map[string]*domain.User{
+		"id":                    sameID,
+		"document":              sameDocument,
+		"email":                 sameEmail,
+		"email in another case": sameEmailOtherCase,
+	} {
+		err := repo.Save(ctx, user)
+		if !errors.Is(err, domain.ErrConflictingData) {
+			t.Fatalf("Save with the same %s: got error %v, want %v", name, err, domain.ErrConflictingData)
+		}
+	}
+
+	users, err := repo.List(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("List: unexpected error: %v", err)
+	}
+
+	if len(users) != 1 {
+		t.Fatalf("got %d users after conflicting saves, want 1", len(users))
+	}
+}
+
+// testInvalidData saves and updates users the schema rejects. Only the age is reported with its
+// field, Postgres does not name the column of a value too long for it.
+func testInvalidData(t *testing.T, repo repository.UserRepository) {
+	ctx := context.Background()
+	mustSave(t, repo, newUser(1))
+
+	tests := []struct {
+		name   string
+		modify func(user *domain.User)
+		field  string
+	}{
+		{"negative age", func(user *domain.User) { user.Age = -1 }, "age"},
+		{"age over 150", func(user *domain.User) { user.Age = 151 }, "age"},
+		{"oversized email", func(user *domain.User) { user.Email = strings.Repeat("a", 243) + "@example.com" }, ""},
+		{"oversized document", func(user *domain.User) { user.Document = strings.Repeat("1", 21) }, ""},
+		{"oversized name", func(user *domain.User) { user.Name = strings.Repeat("é", 256) }, ""},
+	}
+
+	for _, tt := range tests {
+		for op, write := range map[string]func(user *domain.User) error{
+			"Save":   func(user *domain.User) error { return repo.Save(ctx, user) },
+			"Update": func(user *domain.User) error { return repo.Update(ctx, user) },
+		} {
+			user := newUser(2)
+			if op == "Update" {
+				user = newUser(1)
+			}
+			tt.modify(user)
+
+			err := write(user)
+			if !errors.Is(err, domain.ErrInvalidData) {
+				t.Fatalf("%s with %s: got error %v, want %v", op, tt.name, err, domain.ErrInvalidData)
+			}
+			if details := domain.AsError(err).Details; tt.field != "" && (len(details) != 1 || details[0].Field != tt.field) {
+				t.Fatalf("%s with %s: got details %+v, want field %s", op, tt.name, details, tt.field)
+			}
+		}
+	}
+
+	got, err := repo.Get(ctx, newUser(1).ID)
+	if err != nil {
+		t.Fatalf("Get: unexpected error: %v", err)
+	}
+	assertSameUser(t, got, newUser(1))
+
+	_, err = repo.Get(ctx, newUser(2).ID)
+	assertErr(t, err, domain.ErrDataNotFound)
+}
+
+func testGetNotFound(t *testing.T, repo repository.UserRepository) {
+	_, err := repo.Get(context.Background(), "missing")
+	assertErr(t, err, domain.ErrDataNotFound)
+}
+
+func testListOrderAndPagination(t *testing.T, repo repository.UserRepository) {
+	ctx := context.Background()
+
+	users, err := repo.List(ctx, 0, 5)
+	if err != nil {
+		t.Fatalf("List on an empty repository: unexpected error: %v", err)
+	}
+	if len(users) != 0 {
+		t.Fatalf("got %d users on an empty repository, want 0", len(users))
+	}
+
+	// Saved out of order to check List sorts by id
+	for _, n := range []int{5, 2, 7, 1, 4, 3, 6} {
+		mustSave(t, repo, newUser(n))
+	}
+
+	tests := []struct {
+		skip, limit uint64
+		want        []int
+	}{
+		{0, 3, []int{1, 2, 3}},
+		{1, 3, []int{4, 5, 6}},
+		{2, 3, []int{7}},
+		{3, 3, nil},
+		{0, 7, []int{1, 2, 3, 4, 5, 6, 7}},
+		{0, 10, []int{1, 2, 3, 4, 5, 6, 7}},
+		{1, 7, nil},
+		{0, 0, nil},
+	}
+
+	for _, tt := range tests {
+		users, err := repo.List(ctx, tt.skip, tt.limit)
+		if err != nil {
+			t.Fatalf("List(%d, %d): unexpected error: %v", tt.skip, tt.limit, err)
+		}
+
+		var got []string
+		for _, user := range users {
+			got = append(got, user.ID)
+		}
+
+		var want []string
+		for _, n := range tt.want {
+			want = append(want, newUser(n).ID)
+		}
+
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("List(%d, %d): got %v, want %v", tt.skip, tt.limit, got, want)
+		}
+	}
+}
+
+func testUpdate(t *testing.T, repo repository.UserRepository) {
+	ctx := context.Background()
+	user := newUser(1)
+	mustSave(t, repo, user)
+	createdAt := user.CreatedAt
+
+	updated := newUser(2)
+	updated.ID = user.ID
+
+	if err := repo.Update(ctx, updated); err != nil {
+		t.Fatalf("Update: unexpected error: %v", err)
+	}
+
+	if !updated.CreatedAt.Equal(createdAt) {
+		t.Fatalf("Update changed created_at from %v to %v", createdAt, updated.CreatedAt)
+	}
+	if updated.UpdatedAt.Before(createdAt) {
+		t.Fatalf("Update set updated_at %v before created_at %v", updated.UpdatedAt, createdAt)
+	}
+
+	got, err := repo.Get(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Get: unexpected error: %v", err)
+	}
+
+	want := newUser(2)
+	want.ID = user.ID
+	assertSameUser(t, got, want)
+}
+
+func testUpdateConflict(t *testing.T, repo repository.UserRepository) {
+	ctx := context.Background()
+	mustSave(t, repo, newUser(1))
+	mustSave(t, repo, newUser(2))
+
+	updated := newUser(2)
+	updated.Email = newUser(1).Email
+
+	err := repo.Update(ctx, updated)
+	assertErr(t, err, domain.ErrConflictingData)
+
+	got, err := repo.Get(ctx, newUser(2).ID)
+	if err != nil {
+		t.Fatalf("Get: unexpected error: %v", err)
+	}
+	assertSameUser(t, got, newUser(2))
+
+	history, err := repo.History(ctx, newUser(2).ID, 0, 10)
+	if err != nil {
+		t.Fatalf("History: unexpected error: %v", err)
+	}
+	if len(history) != 0 {
+		t.Fatalf("a failed update left %d history rows, want 0", len(history))
+	}
+}
+
+func testUpdateNotFound(t *testing.T, repo repository.UserRepository) {
+	err := repo.Update(context.Background(), newUser(1))
+	assertErr(t, err, domain.ErrDataNotFound)
+}
+
+func testDelete(t *testing.T, repo repository.UserRepository) {
+	ctx := context.Background()
+	mustSave(t, repo, newUser(1))
+	mustSave(t, repo, newUser(2))
+
+	if err := repo.Delete(ctx, newUser(1).ID); err != nil {
+		t.Fatalf("Delete: unexpected error: %v", err)
+	}
+
+	_, err := repo.Get(ctx, newUser(1).ID)
+	assertErr(t, err, domain.ErrDataNotFound)
+
+	if _, err := repo.Get(ctx, newUser(2).ID); err != nil {
+		t.Fatalf("Delete removed another user: %v", err)
+	}
+
+	// The unique columns of a deleted user are free again
+	mustSave(t, repo, newUser(1))
+}
+
+func testDeleteNotFound(t *testing.T, repo repository.UserRepository) {
+	err := repo.Delete(context.Background(), "missing")
+	assertErr(t, err, domain.ErrDataNotFound)
+}
+
+func testHistoryAndGetAsOf(t *testing.T, repo repository.UserRepository) {
+	ctx := context.Background()
+	user := newUser(1)
+
+	beforeCreate := time.Now().Add(-time.Second)
+	mustSave(t, repo, user)
+	created := *user
+
+	// Versions must not share a timestamp for GetAsOf to tell them apart
+	time.Sleep(10 * time.Millisecond)
+
+	updated := newUser(2)
+	updated.ID = user.ID
+	if err := repo.Update(ctx, updated); err != nil {
+		t.Fatalf("Update: unexpected error: %v", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	if err := repo.Delete(ctx, user.ID); err != nil {
+		t.Fatalf("Delete: unexpected error: %v", err)
+	}
+
+	history, err := repo.History(ctx, user.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("History: unexpected error: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("got %d history rows, want 2", len(history))
+	}
+
+	if history[0].Operation != domain.UserOperationDelete || history[1].Operation != domain.UserOperationUpdate {
+		t.Fatalf("got operations %s, %s, want %s, %s", history[0].Operation, history[1].Operation,
+			domain.UserOperationDelete, domain.UserOperationUpdate)
+	}
+	assertSameUser(t, &history[0].User, updated)
+	assertSameUser(t, &history[1].User, &created)
+
+	if !history[1].ValidTo.Equal(history[0].ValidFrom) {
+		t.Fatalf("versions are not contiguous: first ends at %v, second starts at %v", history[1].ValidTo, history[0].ValidFrom)
+	}
+
+	page, err := repo.History(ctx, user.ID, 1, 1)
+	if err != nil {
+		t.Fatalf("History(1, 1): unexpected error: %v", err)
+	}
+	if len(page) != 1 || page[0].Operation != domain.UserOperationUpdate {
+		t.Fatalf("History(1, 1): got %+v, want the update version", page)
+	}
+
+	got, err := repo.GetAsOf(ctx, user.ID, history[1].ValidFrom)
+	if err != nil {
+		t.Fatalf("GetAsOf(created): unexpected error: %v", err)
+	}
+	assertSameUser(t, got, &created)
+
+	got, err = repo.GetAsOf(ctx, user.ID, history[0].ValidFrom)
+	if err != nil {
+		t.Fatalf("GetAsOf(updated): unexpected error: %v", err)
+	}
+	assertSameUser(t, got, updated)
+
+	_, err = repo.GetAsOf(ctx, user.ID, beforeCreate)
+	assertErr(t, err, domain.ErrDataNotFound)
+
+	_, err = repo.GetAsOf(ctx, user.ID, history[0].ValidTo)
+	assertErr(t, err, domain.ErrDataNotFound)
+
+	mustSave(t, repo, newUser(3))
+	got, err = repo.GetAsOf(ctx, newUser(3).ID, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("GetAsOf(current): unexpected error: %v", err)
+	}
+	assertSameUser(t, got, newUser(3))
+}
+
+func testTransactionRollback(t *testing.T, repo repository.UserRepository) {
+	transactor, ok := repo.(repository.Transactor)
+	if !ok {
+		t.Skip("repository does not implement repository.Transactor")
+	}
+
+	errRollback := errors.New("rollback")
+	ctx := context.Background()
+	mustSave(t, repo, newUser(1))
+
+	err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.Save(ctx, newUser(2)); err != nil {
+			return err
+		}
+
+		updated := newUser(3)
+		updated.ID = newUser(1).ID
+		if err := repo.Update(ctx, updated); err != nil {
+			return err
+		}
+
+		return errRollback
+	})
+	assertErr(t, err, errRollback)
+
+	_, err = repo.Get(ctx, newUser(2).ID)
+	assertErr(t, err, domain.ErrDataNotFound)
+
+	got, err := repo.Get(ctx, newUser(1).ID)
+	if err != nil {
+		t.Fatalf("Get: unexpected error: %v", err)
+	}
+	assertSameUser(t, got, newUser(1))
+
+	history, err := repo.History(ctx, newUser(1).ID, 0, 10)
+	if err != nil {
+		t.Fatalf("History: unexpected error: %v", err)
+	}
+	if len(history) != 0 {
+		t.Fatalf("a rolled back update left %d history rows, want 0", len(history))
+	}
+}
+
+func testGetForUpdate(t *testing.T, repo repository.UserRepository) {
+	transactor, ok := repo.(repository.Transactor)
+	if !ok {
+		t.Skip("repository does not implement repository.Transactor")
+	}
+
+	ctx := context.Background()
+	mustSave(t, repo, newUser(1))
+
+	err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		got, err := repo.GetForUpdate(ctx, newUser(1).ID)
+		if err != nil {
+			return err
+		}
+		assertSameUser(t, got, newUser(1))
+
+		_, err = repo.GetForUpdate(ctx, "missing")
+		assertErr(t, err, domain.ErrDataNotFound)
+
+		updated := newUser(2)
+		updated.ID = newUser(1).ID
+		return repo.Update(ctx, updated)
+	})
+	if err != nil {
+		t.Fatalf("WithinTransaction: unexpected error: %v", err)
+	}
+}
+
+func testConcurrentSaves(t *testing.T, repo repository.UserRepository) {
+	const workers = 20
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for n := 1; n <= workers; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			errs <- repo.Save(ctx, newUser(n))
+		}(n)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent Save: unexpected error: %v", err)
+		}
+	}
+
+	users, err := repo.List(ctx, 0, workers*2)
+	if err != nil {
+		t.Fatalf("List: unexpected error: %v", err)
+	}
+	if len(users) != workers {
+		t.Fatalf("got %d users, want %d", len(users), workers)
+	}
+
+	ids := make([]string, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	if !sort.StringsAreSorted(ids) {
+		t.Fatalf("List is not ordered by id: %v", ids)
+	}
+}
+
+func testConcurrentConflictingSaves(t *testing.T, repo repository.UserRepository) {
+	const workers = 20
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for n := 1; n <= workers; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			user := newUser(n)
+			user.Email = "shared@example.com"
+			errs <- repo.Save(ctx, user)
+		}(n)
+	}
+	wg.Wait()
+	close(errs)
+
+	saved := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			saved++
+		case !errors.Is(err, domain.ErrConflictingData):
+			t.Fatalf("concurrent conflicting Save: got error %v, want %v", err, domain.ErrConflictingData)
+		}
+	}
+
+	if saved != 1 {
+		t.Fatalf("%d concurrent saves with the same email succeeded, want 1", saved)
+	}
+}
+
+func testConcurrentUpdates(t *testing.T, repo repository.UserRepository) {
+	const workers = 10
+	ctx := context.Background()
+	mustSave(t, repo, newUser(1))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for n := 1; n <= workers; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			user := newUser(1)
+			user.Name = fmt.Sprintf("Update %d", n)
+			errs <- repo.Update(ctx, user)
+		}(n)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent Update: unexpected error: %v", err)
+		}
+	}
+
+	history, err := repo.History(ctx, newUser(1).ID, 0, workers*2)
+	if err != nil {
+		t.Fatalf("History: unexpected error: %v", err)
+	}
+	if len(history) != workers {
+		t.Fatalf("got %d history rows after %d updates, want %d", len(history), workers, workers)
+	}
+}
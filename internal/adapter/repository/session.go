@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type sessionKey struct{}
+
+// session remembers whether a request already wrote to the database, so adapters
+// with read replicas can keep serving its reads from the primary
+type session struct {
+	wrote atomic.Bool
+}
+
+// WithSession starts a read-your-writes session, usually one per request
+func WithSession(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+// MarkWritten records that the session bound to ctx performed a write
+func MarkWritten(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		s.wrote.Store(true)
+	}
+}
+
+// HasWritten reports whether the session bound to ctx performed a write
+func HasWritten(ctx context.Context) bool {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	return ok && s.wrote.Load()
+}
+
+type primaryKey struct{}
+
+// WithPrimary returns a copy of ctx whose reads are served by the primary, for the reads that
+// must not lag behind the committed writes, such as the ones filling a cache
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// ReadsPrimary reports whether the reads of ctx must be served by the primary, because its
+// session performed a write or it was returned by WithPrimary
+func ReadsPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary || HasWritten(ctx)
+}
@@ -3,12 +3,36 @@ package repository
 import (
 	"context"
 	"go-clean-arch/internal/core/domain"
+	"time"
 )
 
 type UserRepository interface {
 	Save(ctx context.Context, user *domain.User) error
 	List(ctx context.Context, skip, limit uint64) ([]domain.User, error)
 	Get(ctx context.Context, id string) (*domain.User, error)
+	// GetForUpdate is Get keeping other transactions from changing the user until the
+	// transaction of ctx ends, so what it returns is still current when it is written
+	GetForUpdate(ctx context.Context, id string) (*domain.User, error)
 	Update(ctx context.Context, user *domain.User) error
 	Delete(ctx context.Context, id string) error
+	History(ctx context.Context, id string, skip, limit uint64) ([]domain.UserVersion, error)
+	GetAsOf(ctx context.Context, id string, asOf time.Time) (*domain.User, error)
+}
+
+type AuditRepository interface {
+	SaveAudit(ctx context.Context, log *domain.AuditLog) error
+	ListAudit(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, error)
+}
+
+// Transactor runs fn inside a single transaction, every repository call made with
+// the context handed to fn takes part in it. Nested calls join the outer transaction.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Database is implemented by the storage adapters able to back the whole application
+type Database interface {
+	UserRepository
+	AuditRepository
+	Transactor
 }
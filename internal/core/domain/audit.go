@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+// AuditAction identifies the kind of mutation recorded in an audit log
+type AuditAction string
+
+const (
+	// AuditActionCreate is recorded when a user is created
+	AuditActionCreate AuditAction = "user.create"
+	// AuditActionUpdate is recorded when a user is updated
+	AuditActionUpdate AuditAction = "user.update"
+	// AuditActionDelete is recorded when a user is deleted
+	AuditActionDelete AuditAction = "user.delete"
+)
+
+// AuditTargetUser is the target type of audit logs about users
+const AuditTargetUser = "user"
+
+// AuditChange holds the previous and the new value of a changed field
+type AuditChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// AuditLog records who performed a mutation, on what and when. ActorVerified tells
+// whether the actor was authenticated, otherwise it was only claimed by the client.
+type AuditLog struct {
+	ID            string
+	Actor         string
+	ActorVerified bool
+	Action        AuditAction
+	TargetType    string
+	TargetID      string
+	Changes       map[string]AuditChange
+	RequestID     string
+	ClientIP      string
+	CreatedAt     time.Time
+}
+
+// AuditFilter narrows down the audit logs returned by a listing
+type AuditFilter struct {
+	Actor    string
+	Action   AuditAction
+	TargetID string
+	From     time.Time
+	To       time.Time
+	Skip     uint64
+	Limit    uint64
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "context"
+
+type requestMetadataKey struct{}
+
+// RequestMetadata describes who issued a request and where it came from. ActorVerified
+// tells whether the actor was authenticated, otherwise it is only claimed by the client.
+type RequestMetadata struct {
+	Actor         string
+	ActorVerified bool
+	RequestID     string
+	ClientIP      string
+}
+
+// WithRequestMetadata returns a copy of ctx carrying the request metadata
+func WithRequestMetadata(ctx context.Context, md RequestMetadata) context.Context {
+	return context.WithValue(ctx, requestMetadataKey{}, md)
+}
+
+// RequestMetadataFromContext returns the request metadata stored in ctx, if any
+func RequestMetadataFromContext(ctx context.Context) RequestMetadata {
+	md, _ := ctx.Value(requestMetadataKey{}).(RequestMetadata)
+	return md
+}
+
+// RequestIDFromContext returns the ID of the request ctx belongs to, empty outside of a request
+func RequestIDFromContext(ctx context.Context) string {
+	return RequestMetadataFromContext(ctx).RequestID
+}
@@ -2,37 +2,147 @@ package domain
 
 import "errors"
 
+// ErrorCode is a stable, machine-readable identifier of a domain error
+type ErrorCode string
+
+const (
+	CodeInternal                   ErrorCode = "internal"
+	CodeDataNotFound               ErrorCode = "data_not_found"
+	CodeNoUpdatedData              ErrorCode = "no_updated_data"
+	CodeConflictingData            ErrorCode = "conflicting_data"
+	CodeInvalidData                ErrorCode = "invalid_data"
+	CodeInvalidRequest             ErrorCode = "invalid_request"
+	CodeConcurrentUpdate           ErrorCode = "concurrent_update"
+	CodeUnavailable                ErrorCode = "unavailable"
+	CodeTooManyRequests            ErrorCode = "too_many_requests"
+	CodeInsufficientStock          ErrorCode = "insufficient_stock"
+	CodeInsufficientPayment        ErrorCode = "insufficient_payment"
+	CodeTokenDuration              ErrorCode = "token_duration"
+	CodeTokenCreation              ErrorCode = "token_creation"
+	CodeExpiredToken               ErrorCode = "expired_token"
+	CodeInvalidToken               ErrorCode = "invalid_token"
+	CodeInvalidCredentials         ErrorCode = "invalid_credentials"
+	CodeEmptyAuthorizationHeader   ErrorCode = "empty_authorization_header"
+	CodeInvalidAuthorizationHeader ErrorCode = "invalid_authorization_header"
+	CodeInvalidAuthorizationType   ErrorCode = "invalid_authorization_type"
+	CodeUnauthorized               ErrorCode = "unauthorized"
+	CodeForbidden                  ErrorCode = "forbidden"
+)
+
 var (
 	// ErrInternal is an error for when an internal service fails to process the request
-	ErrInternal = errors.New("internal error")
+	ErrInternal = NewError(CodeInternal, "internal error")
 	// ErrDataNotFound is an error for when requested data is not found
-	ErrDataNotFound = errors.New("data not found")
+	ErrDataNotFound = NewError(CodeDataNotFound, "data not found")
 	// ErrNoUpdatedData is an error for when no data is provided to update
-	ErrNoUpdatedData = errors.New("no data to update")
+	ErrNoUpdatedData = NewError(CodeNoUpdatedData, "no data to update")
 	// ErrConflictingData is an error for when data conflicts with existing data
-	ErrConflictingData = errors.New("data conflicts with existing data in unique column")
+	ErrConflictingData = NewError(CodeConflictingData, "data conflicts with existing data in unique column")
+	// ErrInvalidData is an error for when data violates a check, not null or foreign key constraint
+	ErrInvalidData = NewError(CodeInvalidData, "data violates a constraint")
+	// ErrInvalidRequest is an error for when the request does not pass validation
+	ErrInvalidRequest = NewError(CodeInvalidRequest, "invalid request")
+	// ErrConcurrentUpdate is an error for when a change could not be serialized with a concurrent one
+	ErrConcurrentUpdate = NewError(CodeConcurrentUpdate, "data was changed concurrently, try again")
+	// ErrUnavailable is an error for when a dependency such as the database is temporarily unavailable
+	ErrUnavailable = NewError(CodeUnavailable, "service temporarily unavailable")
+	// ErrTooManyRequests is an error for when a client exceeds the rate limit
+	ErrTooManyRequests = NewError(CodeTooManyRequests, "too many requests, try again later")
 	// ErrInsufficientStock is an error for when product stock is not enough
-	ErrInsufficientStock = errors.New("product stock is not enough")
+	ErrInsufficientStock = NewError(CodeInsufficientStock, "product stock is not enough")
 	// ErrInsufficientPayment is an error for when total paid is less than total price
-	ErrInsufficientPayment = errors.New("total paid is less than total price")
+	ErrInsufficientPayment = NewError(CodeInsufficientPayment, "total paid is less than total price")
 	// ErrTokenDuration is an error for when the token duration format is invalid
-	ErrTokenDuration = errors.New("invalid token duration format")
+	ErrTokenDuration = NewError(CodeTokenDuration, "invalid token duration format")
 	// ErrTokenCreation is an error for when the token creation fails
-	ErrTokenCreation = errors.New("error creating token")
+	ErrTokenCreation = NewError(CodeTokenCreation, "error creating token")
 	// ErrExpiredToken is an error for when the access token is expired
-	ErrExpiredToken = errors.New("access token has expired")
+	ErrExpiredToken = NewError(CodeExpiredToken, "access token has expired")
 	// ErrInvalidToken is an error for when the access token is invalid
-	ErrInvalidToken = errors.New("access token is invalid")
+	ErrInvalidToken = NewError(CodeInvalidToken, "access token is invalid")
 	// ErrInvalidCredentials is an error for when the credentials are invalid
-	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrInvalidCredentials = NewError(CodeInvalidCredentials, "invalid email or password")
 	// ErrEmptyAuthorizationHeader is an error for when the authorization header is empty
-	ErrEmptyAuthorizationHeader = errors.New("authorization header is not provided")
+	ErrEmptyAuthorizationHeader = NewError(CodeEmptyAuthorizationHeader, "authorization header is not provided")
 	// ErrInvalidAuthorizationHeader is an error for when the authorization header is invalid
-	ErrInvalidAuthorizationHeader = errors.New("authorization header format is invalid")
+	ErrInvalidAuthorizationHeader = NewError(CodeInvalidAuthorizationHeader, "authorization header format is invalid")
 	// ErrInvalidAuthorizationType is an error for when the authorization type is invalid
-	ErrInvalidAuthorizationType = errors.New("authorization type is not supported")
+	ErrInvalidAuthorizationType = NewError(CodeInvalidAuthorizationType, "authorization type is not supported")
 	// ErrUnauthorized is an error for when the user is unauthorized
-	ErrUnauthorized = errors.New("user is unauthorized to access the resource")
+	ErrUnauthorized = NewError(CodeUnauthorized, "user is unauthorized to access the resource")
 	// ErrForbidden is an error for when the user is forbidden to access the resource
-	ErrForbidden = errors.New("user is forbidden to access the resource")
+	ErrForbidden = NewError(CodeForbidden, "user is forbidden to access the resource")
 )
+
+// ErrorDetail describes what is wrong with a single field
+type ErrorDetail struct {
+	Field   string
+	Message string
+}
+
+// Error is the error type of the domain. Code identifies the kind of error, Message is safe to
+// show to clients, Details list the offending fields and Cause keeps the underlying error for logs.
+// Two errors are the same for errors.Is when their codes are equal, so the variables above can be
+// refined with the With methods and still be matched against.
+type Error struct {
+	Code    ErrorCode
+	Message string
+	Details []ErrorDetail
+	Cause   error
+}
+
+// NewError returns an error with the given code and message
+func NewError(code ErrorCode, message string) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of e caused by cause
+func (e *Error) Wrap(cause error) *Error {
+	err := *e
+	err.Cause = cause
+	return &err
+}
+
+// WithMessage returns a copy of e with the given message
+func (e *Error) WithMessage(message string) *Error {
+	err := *e
+	err.Message = message
+	return &err
+}
+
+// WithDetails returns a copy of e with details appended to its own
+func (e *Error) WithDetails(details ...ErrorDetail) *Error {
+	err := *e
+	err.Details = append(append([]ErrorDetail(nil), e.Details...), details...)
+	return &err
+}
+
+// AsError returns the domain error in the chain of err, or ErrInternal caused by err when there is none
+func AsError(err error) *Error {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr
+	}
+
+	return ErrInternal.Wrap(err)
+}
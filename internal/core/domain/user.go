@@ -1,15 +1,45 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"go-clean-arch/internal/core/redact"
+	"time"
+)
 
 // Change this to your domain
+// Fields tagged redact are masked when a user is logged.
 type User struct {
 	ID        string
-	Document  string
+	Document  string `redact:"true"`
 	Name      string
-	Email     string
+	Email     string `redact:"email"`
 	Age       int
-	Password  string
+	Password  string `redact:"true"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+// Format prints the user with its fields tagged redact masked, so printing it with fmt does not leak them
+func (u User) Format(f fmt.State, verb rune) {
+	redact.Format(f, verb, u)
+}
+
+// UserVersion is a past state of a user, valid from ValidFrom until ValidTo
+type UserVersion struct {
+	User
+	Operation string
+	ValidFrom time.Time
+	ValidTo   time.Time
+}
+
+// Format prints the version with the fields of its user tagged redact masked
+func (v UserVersion) Format(f fmt.State, verb rune) {
+	redact.Format(f, verb, v)
+}
+
+const (
+	// UserOperationUpdate marks a version replaced by an update
+	UserOperationUpdate = "UPDATE"
+	// UserOperationDelete marks a version removed by a delete
+	UserOperationDelete = "DELETE"
+)
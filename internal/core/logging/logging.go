@@ -0,0 +1,37 @@
+// Package logging carries the request-scoped logger through contexts, so every layer handling a
+// request logs with its request ID, user ID and route, and with the trace it is part of.
+package logging
+
+import (
+	"context"
+	"go-clean-arch/internal/core/domain"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying logger
+func NewContext(ctx context.Context, logger *zap.SugaredLogger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or fallback annotated with the request ID of ctx
+// when it carries none, annotated with the trace and span IDs of ctx when it is traced
+func FromContext(ctx context.Context, fallback *zap.SugaredLogger) *zap.SugaredLogger {
+	logger, ok := ctx.Value(ctxKey{}).(*zap.SugaredLogger)
+	if !ok {
+		logger = fallback
+		if requestID := domain.RequestIDFromContext(ctx); requestID != "" {
+			logger = logger.With("request_id", requestID)
+		}
+	}
+
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		return logger
+	}
+
+	return logger.With("trace_id", spanCtx.TraceID().String(), "span_id", spanCtx.SpanID().String())
+}
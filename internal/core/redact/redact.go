@@ -0,0 +1,232 @@
+// Package redact masks the sensitive data of values before they leave the application, in logs,
+// in the audit log or printed through fmt: the struct fields tagged redact, the fields, map entries
+// and log fields named after sensitive keys, and the secrets found in free text.
+package redact
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Placeholder replaces the sensitive values
+const Placeholder = "[REDACTED]"
+
+// The redact struct tag marks the fields never logged as they are: redact:"true" masks the whole
+// value and redact:"email" keeps the first letter and the domain of an email address.
+const (
+	redactTag   = "redact"
+	redactFull  = "true"
+	redactEmail = "email"
+)
+
+// sensitiveKeys are masked wherever they name a struct field, a map entry or a log field,
+// whether or not they are tagged. Keys are compared lower cased, with dashes as underscores.
+var sensitiveKeys = map[string]string{
+	"password":      redactFull,
+	"document":      redactFull,
+	"token":         redactFull,
+	"access_token":  redactFull,
+	"refresh_token": redactFull,
+	"authorization": redactFull,
+	"cookie":        redactFull,
+	"set_cookie":    redactFull,
+	"secret":        redactFull,
+	"api_key":       redactFull,
+	"x_api_key":     redactFull,
+	"email":         redactEmail,
+}
+
+// sensitiveSuffixes are masked like sensitiveKeys when a key ends with them, as in db_password
+var sensitiveSuffixes = []string{"_password", "_token", "_secret", "_authorization"}
+
+// maxDepth bounds the values walked by Value, deeper ones are masked
+const maxDepth = 10
+
+// redactMode returns how the value of key is masked, if it is sensitive
+func redactMode(key string) (string, bool) {
+	key = strings.ReplaceAll(strings.ToLower(key), "-", "_")
+
+	if mode, ok := sensitiveKeys[key]; ok {
+		return mode, true
+	}
+	for _, suffix := range sensitiveSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return redactFull, true
+		}
+	}
+
+	return "", false
+}
+
+// Key returns value masked when key names sensitive data, and whether it does
+func Key(key string, value any) (any, bool) {
+	mode, ok := redactMode(key)
+	if !ok {
+		return value, false
+	}
+
+	return mask(mode, value), true
+}
+
+// mask returns value masked as mode says, empty values are kept as they tell nothing
+func mask(mode string, value any) any {
+	s, isString := value.(string)
+	if isString && s == "" {
+		return s
+	}
+
+	if mode == redactEmail && isString {
+		return Email(s)
+	}
+
+	return Placeholder
+}
+
+// Email keeps the first letter and the domain of an email address, john@example.com is j***@example.com
+func Email(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return Placeholder
+	}
+
+	return local[:1] + "***@" + domain
+}
+
+// Value returns a copy of v that can be logged: struct fields tagged redact, and fields and map
+// entries named after sensitive keys, are masked. Structs are returned as maps keyed by their JSON names.
+func Value(v any) any {
+	return redact(reflect.ValueOf(v), 0)
+}
+
+// Format prints v as fmt would print Value(v). Types holding sensitive data call it from their
+// Format method, so printing them with fmt, as in logger.Error("failed: ", user), masks it too.
+func Format(f fmt.State, verb rune, v any) {
+	fmt.Fprintf(f, fmt.FormatString(f, verb), Value(v))
+}
+
+func redact(v reflect.Value, depth int) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if depth > maxDepth {
+		return Placeholder
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redact(v.Elem(), depth+1)
+	case reflect.Struct:
+		fields := map[string]any{}
+		if !redactStruct(v, fields, depth) {
+			//Values without exported fields, such as time.Time, are logged as they are
+			return v.Interface()
+		}
+		return fields
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		entries := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			if masked, ok := Key(key, valueOf(iter.Value())); ok {
+				entries[key] = masked
+				continue
+			}
+			entries[key] = redact(iter.Value(), depth+1)
+		}
+		return entries
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		items := make([]any, v.Len())
+		for i := range v.Len() {
+			items[i] = redact(v.Index(i), depth+1)
+		}
+		return items
+	}
+
+	if !v.CanInterface() {
+		return nil
+	}
+
+	return v.Interface()
+}
+
+// redactStruct adds the exported fields of the struct v to fields, flattening the embedded
+// structs as encoding/json does, and reports whether it had any
+func redactStruct(v reflect.Value, fields map[string]any, depth int) bool {
+	found := false
+
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		found = true
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		value := v.Field(i)
+		if field.Anonymous && name == "" && value.Kind() == reflect.Struct {
+			redactStruct(value, fields, depth+1)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if mode := field.Tag.Get(redactTag); mode != "" {
+			fields[name] = mask(mode, valueOf(value))
+			continue
+		}
+		if masked, ok := Key(name, valueOf(value)); ok {
+			fields[name] = masked
+			continue
+		}
+
+		fields[name] = redact(value, depth+1)
+	}
+
+	return found
+}
+
+// valueOf returns the value held by v, nil for nil pointers and interfaces
+func valueOf(v reflect.Value) any {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	return v.Interface()
+}
+
+// secretPatterns find the secrets written in free text, such as log messages and error strings
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]+=*`),
+	regexp.MustCompile(`(?i)((?:password|passwd|token|secret|api_key)["']?\s*[:=]\s*["']?)[^\s"',&;]+`),
+}
+
+// Text masks the bearer tokens and the password, token or secret assignments of s
+func Text(s string) string {
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllString(s, "${1}"+Placeholder)
+	}
+
+	return s
+}
@@ -0,0 +1,10 @@
+package usecase
+
+import (
+	"context"
+	"go-clean-arch/internal/core/domain"
+)
+
+type AuditUseCase interface {
+	ListAuditLogs(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, error)
+}
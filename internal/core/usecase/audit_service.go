@@ -0,0 +1,32 @@
+package usecase
+
+import (
+	"context"
+	"go-clean-arch/internal/adapter/repository"
+	"go-clean-arch/internal/core/domain"
+	"go-clean-arch/internal/core/logging"
+
+	"go.uber.org/zap"
+)
+
+type AuditService struct {
+	AuditRepo repository.AuditRepository
+	logger    *zap.SugaredLogger
+}
+
+func NewAuditService(auditRepo repository.AuditRepository, logger *zap.SugaredLogger) *AuditService {
+	return &AuditService{
+		auditRepo,
+		logger,
+	}
+}
+
+func (as *AuditService) ListAuditLogs(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, error) {
+	logs, err := as.AuditRepo.ListAudit(ctx, filter)
+	if err != nil {
+		logging.FromContext(ctx, as.logger).Error("failed to list audit logs: ", err)
+		return nil, domain.AsError(err)
+	}
+
+	return logs, nil
+}
@@ -0,0 +1,24 @@
+package usecase
+
+import (
+	"context"
+	"go-clean-arch/internal/core/domain"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer starts the spans of the service methods, the repository calls they make are nested in them
+var tracer = otel.Tracer("go-clean-arch/internal/core/usecase")
+
+// failed records err on the span of ctx, along with its domain error code
+func failed(ctx context.Context, err error) {
+	code := string(domain.AsError(err).Code)
+
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, code)
+	span.SetAttributes(attribute.String("error.code", code))
+}
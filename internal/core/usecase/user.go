@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"go-clean-arch/internal/core/domain"
+	"time"
 )
 
 type UserUseCase interface {
@@ -11,4 +12,6 @@ type UserUseCase interface {
 	ListUsers(ctx context.Context, skip, limit uint64) ([]domain.User, error)
 	UpdateUser(ctx context.Context, user *domain.User) error
 	DeleteUser(ctx context.Context, id string) error
+	GetUserHistory(ctx context.Context, id string, skip, limit uint64) ([]domain.UserVersion, error)
+	GetUserAsOf(ctx context.Context, id string, asOf time.Time) (*domain.User, error)
 }
@@ -2,95 +2,251 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"go-clean-arch/internal/adapter/repository"
 	"go-clean-arch/internal/core/domain"
+	"go-clean-arch/internal/core/logging"
+	"go-clean-arch/internal/core/redact"
 	"go-clean-arch/internal/utils"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 type UserService struct {
-	UserRepo repository.UserRepository
-	logger   *zap.SugaredLogger
+	UserRepo   repository.UserRepository
+	AuditRepo  repository.AuditRepository
+	Transactor repository.Transactor
+	logger     *zap.SugaredLogger
 }
 
-func NewUserService(userRepo repository.UserRepository, logger *zap.SugaredLogger) *UserService {
+func NewUserService(
+	userRepo repository.UserRepository,
+	auditRepo repository.AuditRepository,
+	transactor repository.Transactor,
+	logger *zap.SugaredLogger,
+) *UserService {
 	return &UserService{
 		userRepo,
+		auditRepo,
+		transactor,
 		logger,
 	}
 }
 
 func (us *UserService) CreateUser(ctx context.Context, user *domain.User) error {
+	ctx, span := tracer.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+
 	hashedPassword, err := utils.HashPassword(user.Password)
 	if err != nil {
-		us.logger.Error("Failed to hash password: ", err)
-		return domain.ErrInternal
+		logging.FromContext(ctx, us.logger).Error("Failed to hash password: ", err)
+		failed(ctx, err)
+		return domain.ErrInternal.Wrap(err)
 	}
 	user.Password = hashedPassword
 
 	utils.BuildIdempotencyKey(user)
 
-	err = us.UserRepo.Save(ctx, user)
+	err = us.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := us.UserRepo.Save(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		return us.audit(ctx, domain.AuditActionCreate, user.ID, nil, user)
+	})
 	if err != nil {
-		if err == domain.ErrConflictingData {
-			us.logger.Error("data already exist: ", err)
+		if errors.Is(err, domain.ErrConflictingData) {
+			logging.FromContext(ctx, us.logger).Error("data already exist: ", err)
+			failed(ctx, err)
 			return err
 		}
-		us.logger.Error("failed to create user: ", err)
-		return domain.ErrInternal
+		logging.FromContext(ctx, us.logger).Error("failed to create user: ", err)
+		failed(ctx, err)
+		return domain.AsError(err)
 	}
 
 	return nil
 }
 
 func (us *UserService) GetUser(ctx context.Context, id string) (*domain.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.GetUser")
+	defer span.End()
+
 	user, err := us.UserRepo.Get(ctx, id)
 	if err != nil {
-		us.logger.Error("failed to get user: ", err)
-		return nil, err
+		logging.FromContext(ctx, us.logger).Error("failed to get user: ", err)
+		failed(ctx, err)
+		return nil, domain.AsError(err)
 	}
 
 	return user, nil
 }
 
 func (us *UserService) ListUsers(ctx context.Context, skip, limit uint64) ([]domain.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.ListUsers")
+	defer span.End()
+
 	users, err := us.UserRepo.List(ctx, skip, limit)
 	if err != nil {
-		us.logger.Error("failed to list users: ", err)
-		return nil, err
+		logging.FromContext(ctx, us.logger).Error("failed to list users: ", err)
+		failed(ctx, err)
+		return nil, domain.AsError(err)
 	}
 
 	return users, nil
 }
 
 func (us *UserService) UpdateUser(ctx context.Context, user *domain.User) error {
-	hashedPassword, err := utils.HashPassword(user.Password)
+	ctx, span := tracer.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+
+	password := user.Password
+	hashedPassword, err := utils.HashPassword(password)
 	if err != nil {
-		us.logger.Error("Failed to hash password: ", err)
-		return domain.ErrInternal
+		logging.FromContext(ctx, us.logger).Error("Failed to hash password: ", err)
+		failed(ctx, err)
+		return domain.ErrInternal.Wrap(err)
 	}
 	user.Password = hashedPassword
 
-	err = us.UserRepo.Update(ctx, user)
-	if err != nil {
-		if err == domain.ErrConflictingData {
-			us.logger.Error("data already exist: ", err)
+	err = us.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		before, err := us.UserRepo.GetForUpdate(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		//Hashes are salted, an unchanged password keeps its hash so it is not audited as changed
+		if utils.ComparePassword(password, before.Password) == nil {
+			user.Password = before.Password
+		}
+
+		err = us.UserRepo.Update(ctx, user)
+		if err != nil {
 			return err
 		}
-		us.logger.Error("failed to update user: ", err)
-		return domain.ErrInternal
+
+		return us.audit(ctx, domain.AuditActionUpdate, user.ID, before, user)
+	})
+	if err != nil {
+		logging.FromContext(ctx, us.logger).Error("failed to update user: ", err)
+		failed(ctx, err)
+		return domain.AsError(err)
 	}
 
 	return nil
 }
 
 func (us *UserService) DeleteUser(ctx context.Context, id string) error {
-	err := us.UserRepo.Delete(ctx, id)
+	ctx, span := tracer.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+
+	err := us.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		before, err := us.UserRepo.GetForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		err = us.UserRepo.Delete(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return us.audit(ctx, domain.AuditActionDelete, id, before, nil)
+	})
 	if err != nil {
-		us.logger.Error("failed to delete user: ", err)
-		return err
+		logging.FromContext(ctx, us.logger).Error("failed to delete user: ", err)
+		failed(ctx, err)
+		return domain.AsError(err)
 	}
 
 	return nil
 }
+
+func (us *UserService) GetUserHistory(ctx context.Context, id string, skip, limit uint64) ([]domain.UserVersion, error) {
+	ctx, span := tracer.Start(ctx, "UserService.GetUserHistory")
+	defer span.End()
+
+	versions, err := us.UserRepo.History(ctx, id, skip, limit)
+	if err != nil {
+		logging.FromContext(ctx, us.logger).Error("failed to get user history: ", err)
+		failed(ctx, err)
+		return nil, domain.AsError(err)
+	}
+
+	return versions, nil
+}
+
+func (us *UserService) GetUserAsOf(ctx context.Context, id string, asOf time.Time) (*domain.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.GetUserAsOf")
+	defer span.End()
+
+	user, err := us.UserRepo.GetAsOf(ctx, id, asOf)
+	if err != nil {
+		logging.FromContext(ctx, us.logger).Error("failed to get user as of ", asOf, ": ", err)
+		failed(ctx, err)
+		return nil, domain.AsError(err)
+	}
+
+	return user, nil
+}
+
+// audit records a user mutation, it must be called with the context of the
+// transaction performing the change so both are committed together
+func (us *UserService) audit(ctx context.Context, action domain.AuditAction, targetID string, before, after *domain.User) error {
+	md := domain.RequestMetadataFromContext(ctx)
+
+	return us.AuditRepo.SaveAudit(ctx, &domain.AuditLog{
+		ID:            utils.GenerateID(),
+		Actor:         md.Actor,
+		ActorVerified: md.ActorVerified,
+		Action:        action,
+		TargetType:    domain.AuditTargetUser,
+		TargetID:      targetID,
+		Changes:       userChanges(before, after),
+		RequestID:     md.RequestID,
+		ClientIP:      md.ClientIP,
+		CreatedAt:     time.Now(),
+	})
+}
+
+// userChanges returns the fields that differ between before and after, a nil
+// user stands for one that does not exist. The document, the email and the
+// password are compared in clear but recorded masked.
+func userChanges(before, after *domain.User) map[string]domain.AuditChange {
+	fields := func(user *domain.User) map[string]any {
+		if user == nil {
+			return map[string]any{}
+		}
+
+		return map[string]any{
+			"id":       user.ID,
+			"document": user.Document,
+			"name":     user.Name,
+			"email":    user.Email,
+			"age":      user.Age,
+			"password": user.Password,
+		}
+	}
+
+	beforeFields, afterFields := fields(before), fields(after)
+
+	changes := map[string]domain.AuditChange{}
+	for _, field := range []string{"id", "document", "name", "email", "age", "password"} {
+		if beforeFields[field] != afterFields[field] {
+			changes[field] = domain.AuditChange{
+				Old: auditValue(field, beforeFields[field]),
+				New: auditValue(field, afterFields[field]),
+			}
+		}
+	}
+
+	return changes
+}
+
+// auditValue returns value as it is recorded in the audit log, masked when field names sensitive data
+func auditValue(field string, value any) any {
+	masked, _ := redact.Key(field, value)
+	return masked
+}
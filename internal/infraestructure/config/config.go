@@ -1,90 +1,295 @@
 package config
 
 import (
-	"os"
-
-	"github.com/joho/godotenv"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
 )
 
+// Every field is named after the environment variable it is read from. The same name, lower cased
+// and split at its first underscore, is its key in configuration files (DB_MAX_CONNS is max_conns
+// in the db section) and, lower cased with dashes, its command line flag (--db-max-conns).
+// Fields tagged secret are redacted when the configuration is printed, fields tagged reload are
+// applied while running when the configuration is reloaded, the others on the next restart.
+//
+// Any value can be read from a file by setting its name suffixed by _FILE to the path of the file
+// instead (DB_PASSWORD_FILE=/run/secrets/db_password), or from the secret provider selected by
+// SECRETS_PROVIDER by setting it to secret:<name> (DB_PASSWORD=secret:db_password).
 type (
 	Container struct {
-		App   *App
-		Token *Token
-		Redis *Redis
-		DB    *DB
-		HTTP  *HTTP
+		App     *App
+		Log     *Log
+		Token   *Token
+		Redis   *Redis
+		DB      *DB
+		HTTP    *HTTP
+		Health  *Health
+		Metrics *Metrics
+		Admin   *Admin
+		Tracing *Tracing
+		Secrets *Secrets
+
+		// file is the configuration file the values were read from, watched for changes
+		file string
 	}
 
 	App struct {
-		Name string
-		Env  string
+		Name string `env:"APP_NAME" default:"go-clean-arch"`
+		Env  string `env:"APP_ENV" default:"production"`
+		// ShutdownTimeout bounds draining the HTTP requests in flight and stopping every component
+		ShutdownTimeout time.Duration `env:"APP_SHUTDOWN_TIMEOUT" default:"30s"`
+	}
+	Log struct {
+		Env string `env:"APP_ENV" default:"production"`
+		// Level is one of debug, info, warn or error, empty is debug in development and info otherwise
+		Level string `env:"LOG_LEVEL" reload:"true"`
+		// Format is json or console, empty is console in development and json otherwise
+		Format string `env:"LOG_FORMAT"`
 	}
 	Token struct {
-		Duration string
+		Duration time.Duration `env:"TOKEN_DURATION" default:"24h"`
 	}
 	Redis struct {
-		Addr     string
-		Password string
+		Addr             string        `env:"REDIS_ADDR"`
+		Password         string        `env:"REDIS_PASSWORD" secret:"true"`
+		CacheTTL         time.Duration `env:"REDIS_CACHE_TTL" default:"5m"`
+		NegativeCacheTTL time.Duration `env:"REDIS_NEGATIVE_CACHE_TTL" default:"30s"`
 	}
 	DB struct {
-		Connection string
-		Host       string
-		Port       string
-		User       string
-		Password   string
-		Name       string
+		Connection  string `env:"DB_CONNECTION" default:"postgres"`
+		Host        string `env:"DB_HOST" default:"127.0.0.1"`
+		Port        int    `env:"DB_PORT" default:"5432"`
+		User        string `env:"DB_USER"`
+		Password    string `env:"DB_PASSWORD" secret:"true"`
+		Name        string `env:"DB_NAME"`
+		AutoMigrate bool   `env:"DB_AUTO_MIGRATE" default:"false"`
+
+		MaxConns          int32         `env:"DB_MAX_CONNS"`
+		MinConns          int32         `env:"DB_MIN_CONNS"`
+		MaxConnLifetime   time.Duration `env:"DB_MAX_CONN_LIFETIME" default:"1h"`
+		MaxConnIdleTime   time.Duration `env:"DB_MAX_CONN_IDLE_TIME" default:"30m"`
+		HealthCheckPeriod time.Duration `env:"DB_HEALTH_CHECK_PERIOD" default:"1m"`
+		StatementTimeout  time.Duration `env:"DB_STATEMENT_TIMEOUT"`
+		ConnectRetries    int           `env:"DB_CONNECT_RETRIES" default:"5"`
+		ConnectBackoff    time.Duration `env:"DB_CONNECT_BACKOFF" default:"1s"`
+
+		SSLMode         string `env:"DB_SSL_MODE" default:"disable"`
+		SSLRootCert     string `env:"DB_SSL_ROOT_CERT"`
+		SSLCert         string `env:"DB_SSL_CERT"`
+		SSLKey          string `env:"DB_SSL_KEY"`
+		ApplicationName string `env:"DB_APPLICATION_NAME" default:"go-clean-arch"`
+
+		Replicas           []string      `env:"DB_REPLICAS" secret:"true"`
+		ReplicaCheckPeriod time.Duration `env:"DB_REPLICA_CHECK_PERIOD" default:"5s"`
 	}
 	HTTP struct {
-		Env            string
-		URL            string
-		Port           string
-		AllowedOrigins string
+		Name           string   `env:"APP_NAME" default:"go-clean-arch"`
+		Env            string   `env:"APP_ENV" default:"production"`
+		URL            string   `env:"HTTP_URL" default:"0.0.0.0"`
+		Port           int      `env:"HTTP_PORT" default:"8080"`
+		AllowedOrigins []string `env:"HTTP_ALLOWED_ORIGINS" reload:"true"`
+		ErrorFormat    string   `env:"HTTP_ERROR_FORMAT" default:"json"`
+		// RateLimit is the number of requests per second allowed to each client IP, zero disables it
+		RateLimit int `env:"HTTP_RATE_LIMIT" reload:"true"`
+		RateBurst int `env:"HTTP_RATE_BURST" default:"20" reload:"true"`
+		// TrustedProxies are the IPs or CIDRs whose X-Forwarded-For and X-Real-IP headers name the
+		// client, empty trusts none so the client is always the peer of the connection
+		TrustedProxies []string `env:"HTTP_TRUSTED_PROXIES"`
+		// ReadHeaderTimeout bounds reading the headers of a request, so slow clients cannot hold connections open
+		ReadHeaderTimeout time.Duration `env:"HTTP_READ_HEADER_TIMEOUT" default:"5s"`
+	}
+	Health struct {
+		// Timeout bounds each readiness check
+		Timeout time.Duration `env:"HEALTH_TIMEOUT" default:"2s"`
+		// ShutdownDelay is how long readiness fails before the HTTP server stops accepting requests on shutdown
+		ShutdownDelay time.Duration `env:"HEALTH_SHUTDOWN_DELAY"`
+	}
+	Metrics struct {
+		URL string `env:"METRICS_URL" default:"0.0.0.0"`
+		// Port serves /metrics apart from the API, zero disables it
+		Port int `env:"METRICS_PORT" default:"9090"`
+	}
+	Admin struct {
+		// URL is loopback by default as the admin endpoints are not authenticated
+		URL string `env:"ADMIN_URL" default:"127.0.0.1"`
+		// Port serves the admin endpoints, such as /log/level, apart from the API and the metrics, zero disables them
+		Port int `env:"ADMIN_PORT" default:"9091"`
+	}
+	Tracing struct {
+		// Exporter is none, stdout or otlp (OTLP over HTTP), the OTEL_EXPORTER_OTLP_* variables are honored
+		Exporter string `env:"TRACING_EXPORTER" default:"none"`
+		// Endpoint is the host:port of the OTLP collector, overriding OTEL_EXPORTER_OTLP_ENDPOINT
+		Endpoint string `env:"TRACING_ENDPOINT"`
+		Insecure bool   `env:"TRACING_INSECURE" default:"false"`
+		// SampleRatio is the share of the traces started here that are recorded, from 0 to 1
+		SampleRatio float64 `env:"TRACING_SAMPLE_RATIO" default:"1"`
+	}
+	Secrets struct {
+		Provider string `env:"SECRETS_PROVIDER" default:"file"`
+		File     string `env:"SECRETS_FILE"`
+		Key      string `env:"SECRETS_KEY" secret:"true"`
+		// ReloadPeriod is how often secrets are read again to follow their rotation, zero disables it
+		ReloadPeriod time.Duration `env:"SECRETS_RELOAD_PERIOD"`
 	}
 )
 
-func New() (*Container, error) {
-	if os.Getenv("APP_ENV") != "production" {
-		err := godotenv.Load()
-		if err != nil {
-			return nil, err
+// New loads the configuration from, in increasing order of precedence, the defaults, the file
+// given by --config or CONFIG_FILE (YAML or TOML), the .env file, the environment and the flags
+// in args. Every invalid or missing value is reported at once.
+func New(args ...string) (*Container, error) {
+	return newContainer(nil, args)
+}
+
+// Load is New for the commands needing only some sections of the configuration, named after the
+// fields of Container, such as the migrations needing DB. The values of the other sections are
+// read but neither resolved from files and secret providers nor validated. Secrets is always loaded.
+func Load(sections []string, args ...string) (*Container, error) {
+	return newContainer(append(slices.Clone(sections), "Secrets"), args)
+}
+
+// newContainer loads the configuration of sections, or every section when it is nil
+func newContainer(sections []string, args []string) (*Container, error) {
+	container := &Container{
+		App:     &App{},
+		Log:     &Log{},
+		Token:   &Token{},
+		Redis:   &Redis{},
+		DB:      &DB{},
+		HTTP:    &HTTP{},
+		Health:  &Health{},
+		Metrics: &Metrics{},
+		Admin:   &Admin{},
+		Tracing: &Tracing{},
+		Secrets: &Secrets{},
+	}
+
+	wanted := map[string]bool{}
+	for _, field := range fields(container) {
+		if sections == nil || slices.Contains(sections, field.section) {
+			wanted[field.env] = true
+		}
+	}
+
+	values, file, err := load(container, args, wanted)
+	if err != nil {
+		return nil, err
+	}
+	container.file = file
+
+	err = errors.Join(decode(container, values, wanted), container.validate(sections))
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	return container, nil
+}
+
+// Validate reports every missing or inconsistent value of the configuration
+func (c *Container) Validate() error {
+	return c.validate(nil)
+}
+
+// validate reports the missing or inconsistent values of sections, of every section when it is nil
+func (c *Container) validate(sections []string) error {
+	var errs []error
+
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	in := func(section string) bool {
+		return sections == nil || slices.Contains(sections, section)
+	}
+
+	if in("App") {
+		check(c.App.Name != "", "APP_NAME is required")
+		check(c.App.ShutdownTimeout > 0, "APP_SHUTDOWN_TIMEOUT must be positive")
+	}
+
+	if in("Log") {
+		check(c.Log.Format == "" || c.Log.Format == "json" || c.Log.Format == "console", "LOG_FORMAT must be json or console")
+		check(c.Log.Level == "" || slices.Contains([]string{"debug", "info", "warn", "error"}, c.Log.Level),
+			"LOG_LEVEL must be one of debug, info, warn or error")
+	}
+
+	if in("Token") {
+		check(c.Token.Duration > 0, "TOKEN_DURATION must be positive")
+	}
+
+	if in("Redis") {
+		check(c.Redis.CacheTTL > 0, "REDIS_CACHE_TTL must be positive")
+		check(c.Redis.NegativeCacheTTL > 0, "REDIS_NEGATIVE_CACHE_TTL must be positive")
+	}
+
+	if in("DB") {
+		switch c.DB.Connection {
+		case "postgres":
+			check(c.DB.Host != "", "DB_HOST is required")
+			check(c.DB.Port > 0 && c.DB.Port < 65536, "DB_PORT must be between 1 and 65535")
+			check(c.DB.User != "", "DB_USER is required")
+			check(c.DB.Name != "", "DB_NAME is required")
+			check(c.DB.MaxConns >= 0, "DB_MAX_CONNS must not be negative")
+			check(c.DB.MinConns >= 0, "DB_MIN_CONNS must not be negative")
+			check(c.DB.MaxConns == 0 || c.DB.MinConns <= c.DB.MaxConns, "DB_MIN_CONNS must not exceed DB_MAX_CONNS")
+			check(c.DB.ConnectRetries >= 0, "DB_CONNECT_RETRIES must not be negative")
+			check(c.DB.ConnectBackoff > 0, "DB_CONNECT_BACKOFF must be positive")
+			check(c.DB.ReplicaCheckPeriod > 0, "DB_REPLICA_CHECK_PERIOD must be positive")
+			check(slices.Contains([]string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}, c.DB.SSLMode),
+				"DB_SSL_MODE must be one of disable, allow, prefer, require, verify-ca or verify-full")
+		case "sqlite":
+			check(c.DB.Name != "", "DB_NAME is required")
+		case "memory":
+		default:
+			check(false, "DB_CONNECTION must be one of postgres, sqlite or memory")
+		}
+	}
+
+	if in("HTTP") {
+		check(c.HTTP.Port > 0 && c.HTTP.Port < 65536, "HTTP_PORT must be between 1 and 65535")
+		check(len(c.HTTP.AllowedOrigins) > 0, "HTTP_ALLOWED_ORIGINS is required")
+		for _, origin := range c.HTTP.AllowedOrigins {
+			check(origin == "*" || strings.HasPrefix(origin, "http://") || strings.HasPrefix(origin, "https://"),
+				"HTTP_ALLOWED_ORIGINS: %q must be * or start with http:// or https://", origin)
+		}
+		check(c.HTTP.ErrorFormat == "json" || c.HTTP.ErrorFormat == "problem", "HTTP_ERROR_FORMAT must be json or problem")
+		check(c.HTTP.RateLimit >= 0, "HTTP_RATE_LIMIT must not be negative")
+		check(c.HTTP.RateLimit == 0 || c.HTTP.RateBurst > 0, "HTTP_RATE_BURST must be positive when HTTP_RATE_LIMIT is set")
+		check(c.HTTP.ReadHeaderTimeout > 0, "HTTP_READ_HEADER_TIMEOUT must be positive")
+		for _, proxy := range c.HTTP.TrustedProxies {
+			_, _, err := net.ParseCIDR(proxy)
+			check(err == nil || net.ParseIP(proxy) != nil, "HTTP_TRUSTED_PROXIES: %q must be an IP or a CIDR", proxy)
 		}
 	}
 
-	app := &App{
-		Name: os.Getenv("APP_NAME"),
-		Env:  os.Getenv("APP_ENV"),
+	if in("Health") {
+		check(c.Health.Timeout > 0, "HEALTH_TIMEOUT must be positive")
+		check(c.Health.ShutdownDelay >= 0, "HEALTH_SHUTDOWN_DELAY must not be negative")
+		check(c.Health.ShutdownDelay < c.App.ShutdownTimeout, "HEALTH_SHUTDOWN_DELAY must be shorter than APP_SHUTDOWN_TIMEOUT")
 	}
 
-	token := &Token{
-		Duration: os.Getenv("TOKEN_DURATION"),
+	if in("Metrics") {
+		check(c.Metrics.Port >= 0 && c.Metrics.Port < 65536, "METRICS_PORT must be between 0 and 65535")
+		check(c.Metrics.Port == 0 || c.Metrics.Port != c.HTTP.Port, "METRICS_PORT must differ from HTTP_PORT")
 	}
 
-	redis := &Redis{
-		Addr:     os.Getenv("REDIS_ADDR"),
-		Password: os.Getenv("REDIS_PASSWORD"),
+	if in("Admin") {
+		check(c.Admin.Port >= 0 && c.Admin.Port < 65536, "ADMIN_PORT must be between 0 and 65535")
+		check(c.Admin.Port == 0 || (c.Admin.Port != c.HTTP.Port && c.Admin.Port != c.Metrics.Port),
+			"ADMIN_PORT must differ from HTTP_PORT and METRICS_PORT")
 	}
 
-	db := &DB{
-		Connection: os.Getenv("DB_CONNECTION"),
-		Host:       os.Getenv("DB_HOST"),
-		Port:       os.Getenv("DB_PORT"),
-		User:       os.Getenv("DB_USER"),
-		Password:   os.Getenv("DB_PASSWORD"),
-		Name:       os.Getenv("DB_NAME"),
+	if in("Tracing") {
+		check(slices.Contains([]string{"none", "stdout", "otlp"}, c.Tracing.Exporter), "TRACING_EXPORTER must be one of none, stdout or otlp")
+		check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO must be between 0 and 1")
 	}
 
-	http := &HTTP{
-		Env:            os.Getenv("APP_ENV"),
-		URL:            os.Getenv("HTTP_URL"),
-		Port:           os.Getenv("HTTP_PORT"),
-		AllowedOrigins: os.Getenv("HTTP_ALLOWED_ORIGINS"),
+	if in("Secrets") {
+		check(c.Secrets.ReloadPeriod >= 0, "SECRETS_RELOAD_PERIOD must not be negative")
 	}
 
-	return &Container{
-		app,
-		token,
-		redis,
-		db,
-		http,
-	}, nil
+	return errors.Join(errs...)
 }
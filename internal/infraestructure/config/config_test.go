@@ -0,0 +1,252 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// required are the flags setting the values without a default a configuration needs to be valid
+var required = []string{"--db-connection=memory", "--http-allowed-origins=http://localhost"}
+
+// isolate runs the test in an empty directory, so without .env file, and without any configuration variable set
+func isolate(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	names := []string{fileEnv}
+	for _, field := range fields(newTestContainer("")) {
+		names = append(names, field.env, field.env+fileSuffix)
+	}
+	for _, name := range names {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+
+	err := os.WriteFile(name, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("WriteFile: unexpected error: %v", err)
+	}
+}
+
+func TestNewPrecedence(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		dotenv string
+		env    string
+		flag   string
+		want   string
+	}{
+		{name: "default", want: "go-clean-arch"},
+		{name: "file", file: "file", want: "file"},
+		{name: "dotenv over file", file: "file", dotenv: "dotenv", want: "dotenv"},
+		{name: "env over dotenv", file: "file", dotenv: "dotenv", env: "env", want: "env"},
+		{name: "flag over env", file: "file", dotenv: "dotenv", env: "env", flag: "flag", want: "flag"},
+		{name: "flag over file", file: "file", flag: "flag", want: "flag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+
+			args := slices.Clone(required)
+			if tt.file != "" {
+				writeFile(t, "config.yaml", "app:\n  name: "+tt.file+"\n")
+				args = append(args, "--config=config.yaml")
+			}
+			if tt.dotenv != "" {
+				writeFile(t, dotenvFile, "APP_NAME="+tt.dotenv+"\n")
+			}
+			if tt.env != "" {
+				t.Setenv("APP_NAME", tt.env)
+			}
+			if tt.flag != "" {
+				args = append(args, "--app-name="+tt.flag)
+			}
+
+			config, err := New(args...)
+			if err != nil {
+				t.Fatalf("New: unexpected error: %v", err)
+			}
+			if config.App.Name != tt.want {
+				t.Fatalf("got APP_NAME %q, want %q", config.App.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "yaml", file: "config.yaml", content: "db:\n  port: 6543\nhttp:\n  allowed_origins: [http://a, http://b]\n"},
+		{name: "toml", file: "config.toml", content: "[db]\nport = 6543\n[http]\nallowed_origins = [\"http://a\", \"http://b\"]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			writeFile(t, tt.file, tt.content)
+			t.Setenv(fileEnv, tt.file)
+
+			config, err := New("--db-connection=memory")
+			if err != nil {
+				t.Fatalf("New: unexpected error: %v", err)
+			}
+			if config.DB.Port != 6543 {
+				t.Fatalf("got DB_PORT %d, want 6543", config.DB.Port)
+			}
+			if want := []string{"http://a", "http://b"}; !reflect.DeepEqual(config.HTTP.AllowedOrigins, want) {
+				t.Fatalf("got HTTP_ALLOWED_ORIGINS %q, want %q", config.HTTP.AllowedOrigins, want)
+			}
+		})
+	}
+}
+
+func TestNewDecode(t *testing.T) {
+	isolate(t)
+
+	config, err := New("--db-connection=memory", "--http-allowed-origins=http://a, http://b,",
+		"--token-duration=90m", "--db-max-conns=8", "--db-auto-migrate=true", "--tracing-sample-ratio=0.5")
+	if err != nil {
+		t.Fatalf("New: unexpected error: %v", err)
+	}
+
+	got := []any{config.HTTP.AllowedOrigins, config.Token.Duration, config.DB.MaxConns, config.DB.AutoMigrate, config.Tracing.SampleRatio}
+	want := []any{[]string{"http://a", "http://b"}, 90 * time.Minute, int32(8), true, 0.5}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		args []string
+		// want are the messages the error must hold, every problem being reported at once
+		want []string
+	}{
+		{
+			name: "invalid values",
+			args: []string{"--db-port=abc", "--token-duration=1x", "--db-auto-migrate=maybe", "--db-max-conns=99999999999"},
+			want: []string{`DB_PORT: invalid value "abc"`, `TOKEN_DURATION: invalid value "1x"`,
+				`DB_AUTO_MIGRATE: invalid value "maybe"`, `DB_MAX_CONNS: invalid value "99999999999"`},
+		},
+		{
+			name: "missing and inconsistent values",
+			args: []string{"--db-connection=postgres", "--http-allowed-origins=", "--metrics-port=8080", "--http-read-header-timeout=0s"},
+			want: []string{"DB_USER is required", "DB_NAME is required", "HTTP_ALLOWED_ORIGINS is required",
+				"METRICS_PORT must differ from HTTP_PORT", "HTTP_READ_HEADER_TIMEOUT must be positive"},
+		},
+		{
+			name: "invalid and missing values",
+			args: []string{"--db-connection=memory", "--http-port=http"},
+			want: []string{`HTTP_PORT: invalid value "http"`, "HTTP_ALLOWED_ORIGINS is required"},
+		},
+		{
+			name: "unknown file key",
+			file: "app:\n  nmae: x\n",
+			args: []string{"--config=config.yaml"},
+			want: []string{"unknown key app_nmae"},
+		},
+		{
+			name: "unknown flag",
+			args: []string{"--db-pasword=x"},
+			want: []string{"flag provided but not defined: -db-pasword"},
+		},
+		{
+			name: "unexpected argument",
+			args: []string{"serve"},
+			want: []string{`unexpected argument "serve"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+			if tt.file != "" {
+				writeFile(t, "config.yaml", tt.file)
+			}
+
+			_, err := New(tt.args...)
+			if err == nil {
+				t.Fatal("New: expected an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("New: error %q does not report %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadSections(t *testing.T) {
+	isolate(t)
+
+	//HTTP is neither decoded nor validated, only DB and Secrets are
+	config, err := Load([]string{"DB"}, "--db-connection=memory", "--http-port=http")
+	if err != nil {
+		t.Fatalf("Load: unexpected error: %v", err)
+	}
+	if config.DB.Connection != "memory" {
+		t.Fatalf("got DB_CONNECTION %q, want memory", config.DB.Connection)
+	}
+
+	_, err = Load([]string{"DB"}, "--db-connection=mongo")
+	if err == nil || !strings.Contains(err.Error(), "DB_CONNECTION must be one of") {
+		t.Fatalf("Load: got error %v, want the invalid DB_CONNECTION reported", err)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	tests := []struct {
+		name   string
+		redact bool
+		// want are the lines printed for the database password, the empty Redis password and the database user
+		want []string
+	}{
+		{
+			name:   "redacted",
+			redact: true,
+			want:   []string{`DB_PASSWORD="[REDACTED]"`, `REDIS_PASSWORD=""`, `DB_USER="app"`},
+		},
+		{
+			name: "in clear",
+			want: []string{`DB_PASSWORD="s3cr3t"`, `REDIS_PASSWORD=""`, `DB_USER="app"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := newTestContainer("s3cr3t")
+			config.DB.User = "app"
+
+			var out strings.Builder
+			err := config.Print(&out, tt.redact)
+			if err != nil {
+				t.Fatalf("Print: unexpected error: %v", err)
+			}
+
+			lines := strings.Split(out.String(), "\n")
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want+"\n") {
+					t.Errorf("Print: %q not printed in %q", want, lines)
+				}
+			}
+			if tt.redact && strings.Contains(out.String(), "s3cr3t") {
+				t.Errorf("Print: the password is printed in %q", lines)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+	"io"
+)
+
+// Print writes the configuration to w in the .env format, with its secrets masked when redact is set
+func (c *Container) Print(w io.Writer, redact bool) error {
+	printed := map[string]bool{}
+
+	for _, field := range fields(c) {
+		if printed[field.env] {
+			continue
+		}
+		printed[field.env] = true
+
+		_, err := fmt.Fprintf(w, "%s=%q\n", field.env, field.display(redact))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
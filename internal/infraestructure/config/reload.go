@@ -0,0 +1,172 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// filePollPeriod is how often the configuration and .env files are checked for changes
+const filePollPeriod = 2 * time.Second
+
+// Watcher holds the configuration in use and replaces it when the configuration is reloaded,
+// on SIGHUP or when one of its files changes
+type Watcher struct {
+	current atomic.Pointer[Container]
+	load    func() (*Container, error)
+	logger  *zap.SugaredLogger
+
+	// mu serializes reloads and the subscribers they notify
+	mu          sync.Mutex
+	subscribers []func(*Container)
+}
+
+// NewWatcher returns a watcher starting from current, load reads the configuration again on every reload
+func NewWatcher(current *Container, load func() (*Container, error), logger *zap.SugaredLogger) *Watcher {
+	w := &Watcher{
+		load:   load,
+		logger: logger,
+	}
+	w.current.Store(current)
+
+	return w
+}
+
+// Current returns the configuration in use
+func (w *Watcher) Current() *Container {
+	return w.current.Load()
+}
+
+// Subscribe calls fn with the new configuration every time a reload changes it
+func (w *Watcher) Subscribe(fn func(*Container)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Reload reads the configuration again and applies the changes of its reloadable values. An invalid
+// configuration is rejected, keeping the current one, and the other values only change on a restart.
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	fresh, err := w.load()
+	if err != nil {
+		return err
+	}
+
+	current := w.Current()
+	next := current.clone()
+
+	currentFields, freshFields, nextFields := fields(current), fields(fresh), fields(next)
+	seen := map[string]bool{}
+	changed := false
+
+	for i, field := range currentFields {
+		if reflect.DeepEqual(field.value.Interface(), freshFields[i].value.Interface()) {
+			continue
+		}
+
+		if !field.reload {
+			if !seen[field.env] {
+				w.logger.Warnf("%s changed, it takes effect on the next restart", field.env)
+			}
+			seen[field.env] = true
+			continue
+		}
+
+		nextFields[i].value.Set(freshFields[i].value)
+		changed = true
+		w.logger.Infof("%s changed from %q to %q", field.env, field.display(true), freshFields[i].display(true))
+	}
+
+	if !changed {
+		w.logger.Info("Configuration reloaded, nothing to apply")
+		return nil
+	}
+
+	w.current.Store(next)
+	for _, fn := range w.subscribers {
+		fn(next)
+	}
+
+	w.logger.Info("Configuration reloaded")
+	return nil
+}
+
+// Watch reloads the configuration on SIGHUP and whenever its configuration or .env file
+// is modified, until ctx is done
+func (w *Watcher) Watch(ctx context.Context) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	ticker := time.NewTicker(filePollPeriod)
+	defer ticker.Stop()
+
+	files := []string{dotenvFile}
+	if file := w.Current().file; file != "" {
+		files = append(files, file)
+	}
+	modified := modTimes(files)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			w.logger.Info("SIGHUP received, reloading the configuration")
+		case <-ticker.C:
+			times := modTimes(files)
+			if reflect.DeepEqual(times, modified) {
+				continue
+			}
+			modified = times
+			w.logger.Info("Configuration file changed, reloading the configuration")
+		}
+
+		err := w.Reload()
+		if err != nil {
+			w.logger.Error("Invalid configuration, keeping the current one: ", err)
+		}
+	}
+}
+
+// modTimes returns the modification time of each file, the zero time for missing ones
+func modTimes(files []string) map[string]time.Time {
+	times := map[string]time.Time{}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err == nil {
+			times[file] = info.ModTime()
+		}
+	}
+
+	return times
+}
+
+// clone returns a copy of the configuration whose sections can be changed without affecting c
+func (c *Container) clone() *Container {
+	clone := *c
+
+	sections := reflect.ValueOf(&clone).Elem()
+	for i := range sections.NumField() {
+		if !sections.Type().Field(i).IsExported() {
+			continue
+		}
+
+		section := reflect.New(sections.Field(i).Type().Elem())
+		section.Elem().Set(sections.Field(i).Elem())
+		sections.Field(i).Set(section)
+	}
+
+	return &clone
+}
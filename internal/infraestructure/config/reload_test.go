@@ -0,0 +1,123 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWatcherReload(t *testing.T) {
+	tests := []struct {
+		name string
+		// change edits the configuration loaded on reload, a nil one fails to load it
+		change func(c *Container)
+		// want is the log level and allowed origins in use after the reload
+		wantLevel   string
+		wantOrigins []string
+		notified    bool
+		err         bool
+		// logs are the messages the reload must log
+		logs []string
+	}{
+		{
+			name:        "nothing changed",
+			change:      func(*Container) {},
+			wantLevel:   "info",
+			wantOrigins: []string{"http://a"},
+			logs:        []string{"Configuration reloaded, nothing to apply"},
+		},
+		{
+			name: "reloadable values",
+			change: func(c *Container) {
+				c.Log.Level = "debug"
+				c.HTTP.AllowedOrigins = []string{"http://a", "http://b"}
+			},
+			wantLevel:   "debug",
+			wantOrigins: []string{"http://a", "http://b"},
+			notified:    true,
+			logs:        []string{`LOG_LEVEL changed from "info" to "debug"`, `HTTP_ALLOWED_ORIGINS changed from "http://a" to "http://a,http://b"`},
+		},
+		{
+			name:        "values applied on restart",
+			change:      func(c *Container) { c.DB.Password = "rotated" },
+			wantLevel:   "info",
+			wantOrigins: []string{"http://a"},
+			logs:        []string{"DB_PASSWORD changed, it takes effect on the next restart"},
+		},
+		{
+			name: "both",
+			change: func(c *Container) {
+				c.Log.Level = "debug"
+				c.DB.Password = "rotated"
+			},
+			wantLevel:   "debug",
+			wantOrigins: []string{"http://a"},
+			notified:    true,
+			logs:        []string{`LOG_LEVEL changed from "info" to "debug"`, "DB_PASSWORD changed, it takes effect on the next restart"},
+		},
+		{
+			name:        "invalid configuration",
+			wantLevel:   "info",
+			wantOrigins: []string{"http://a"},
+			err:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initial := newTestContainer("initial")
+			initial.Log.Level = "info"
+			initial.HTTP.AllowedOrigins = []string{"http://a"}
+
+			load := func() (*Container, error) {
+				if tt.change == nil {
+					return nil, errors.New("invalid configuration")
+				}
+				fresh := initial.clone()
+				tt.change(fresh)
+				return fresh, nil
+			}
+
+			core, logs := observer.New(zap.InfoLevel)
+			watcher := NewWatcher(initial, load, zap.New(core).Sugar())
+
+			var notified []*Container
+			watcher.Subscribe(func(c *Container) { notified = append(notified, c) })
+
+			err := watcher.Reload()
+			if tt.err != (err != nil) {
+				t.Fatalf("Reload: got error %v, want one: %t", err, tt.err)
+			}
+
+			current := watcher.Current()
+			if current.Log.Level != tt.wantLevel || !reflect.DeepEqual(current.HTTP.AllowedOrigins, tt.wantOrigins) {
+				t.Fatalf("got LOG_LEVEL %q and HTTP_ALLOWED_ORIGINS %q, want %q and %q",
+					current.Log.Level, current.HTTP.AllowedOrigins, tt.wantLevel, tt.wantOrigins)
+			}
+			if current.DB.Password != "initial" {
+				t.Fatalf("got DB_PASSWORD %q, want it kept until the next restart", current.DB.Password)
+			}
+
+			//The configuration in use is replaced, never changed in place
+			if initial.Log.Level != "info" || !reflect.DeepEqual(initial.HTTP.AllowedOrigins, []string{"http://a"}) {
+				t.Fatal("the previous configuration was changed in place")
+			}
+
+			if tt.notified && (len(notified) != 1 || notified[0] != current) {
+				t.Fatalf("got %d notifications, want one with the new configuration", len(notified))
+			}
+			if !tt.notified && (len(notified) != 0 || current != initial) {
+				t.Fatalf("got %d notifications, want the configuration kept", len(notified))
+			}
+
+			for _, want := range tt.logs {
+				if logs.FilterMessage(want).Len() != 1 {
+					t.Errorf("%q not logged in %v", want, logs.All())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package config
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// fileSuffix marks a variable holding the path of the file to read a value from, as Docker and Kubernetes secrets are mounted
+	fileSuffix = "_FILE"
+	// secretPrefix marks a value to be resolved by the secret provider, as in DB_PASSWORD=secret:db_password
+	secretPrefix = "secret:"
+)
+
+// SecretProvider resolves the secrets referenced in the configuration
+type SecretProvider interface {
+	Secret(name string) (string, error)
+}
+
+// SecretProviderFactory builds a provider from the raw configuration values, where it finds its own settings
+type SecretProviderFactory func(values map[string]string) (SecretProvider, error)
+
+var (
+	secretProvidersMu sync.RWMutex
+	secretProviders   = map[string]SecretProviderFactory{
+		"file": newEncryptedFileFromValues,
+	}
+)
+
+// RegisterSecretProvider makes a provider selectable through SECRETS_PROVIDER
+func RegisterSecretProvider(name string, factory SecretProviderFactory) {
+	secretProvidersMu.Lock()
+	defer secretProvidersMu.Unlock()
+
+	secretProviders[name] = factory
+}
+
+// readFiles replaces the X_FILE entries of a source by X, with the content of the file they name,
+// the entries of the values that are not wanted are dropped
+func readFiles(source map[string]string, known, wanted map[string]bool) (map[string]string, error) {
+	values := maps.Clone(source)
+	var errs []error
+
+	for key, path := range source {
+		env, ok := strings.CutSuffix(key, fileSuffix)
+		if !ok || !known[env] || known[key] {
+			continue
+		}
+
+		delete(values, key)
+		if !wanted[env] {
+			continue
+		}
+		if _, ok := source[env]; ok {
+			errs = append(errs, fmt.Errorf("%s and %s are both set", env, key))
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+
+		values[env] = strings.TrimRight(string(content), "\r\n")
+	}
+
+	return values, errors.Join(errs...)
+}
+
+// resolveSecrets replaces the secret: references of the wanted values by the secrets they name
+func resolveSecrets(values map[string]string, wanted map[string]bool) error {
+	var refs []string
+	for env, value := range values {
+		if wanted[env] && strings.HasPrefix(value, secretPrefix) {
+			refs = append(refs, env)
+		}
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	name := values["SECRETS_PROVIDER"]
+
+	secretProvidersMu.RLock()
+	factory, ok := secretProviders[name]
+	secretProvidersMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%s references a secret but SECRETS_PROVIDER %q is not a known provider", refs[0], name)
+	}
+
+	provider, err := factory(values)
+	if err != nil {
+		return fmt.Errorf("secret provider %s: %w", name, err)
+	}
+
+	var errs []error
+	for _, env := range refs {
+		secret, err := provider.Secret(strings.TrimPrefix(values[env], secretPrefix))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", env, err))
+			continue
+		}
+		values[env] = secret
+	}
+
+	return errors.Join(errs...)
+}
+
+// EncryptedFile is a secret provider reading a JSON object of secrets encrypted with AES-256-GCM,
+// the nonce followed by the ciphertext, as written by SealSecrets
+type EncryptedFile struct {
+	secrets map[string]string
+}
+
+func newEncryptedFileFromValues(values map[string]string) (SecretProvider, error) {
+	key, err := base64.StdEncoding.DecodeString(values["SECRETS_KEY"])
+	if err != nil {
+		return nil, fmt.Errorf("SECRETS_KEY must be base64 encoded: %w", err)
+	}
+
+	return NewEncryptedFile(values["SECRETS_FILE"], key)
+}
+
+// NewEncryptedFile decrypts the secrets of the file at path with key
+func NewEncryptedFile(path string, key []byte) (*EncryptedFile, error) {
+	sealed, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("secrets file is too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("secrets file cannot be decrypted with SECRETS_KEY")
+	}
+
+	var secrets map[string]string
+	err = json.Unmarshal(plaintext, &secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncryptedFile{secrets}, nil
+}
+
+func (f *EncryptedFile) Secret(name string) (string, error) {
+	secret, ok := f.secrets[name]
+	if !ok {
+		return "", fmt.Errorf("secret %q not found", name)
+	}
+
+	return secret, nil
+}
+
+// SealSecrets encrypts secrets with key in the format read by EncryptedFile
+func SealSecrets(key []byte, secrets map[string]string) ([]byte, error) {
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errors.New("the secrets key must be 32 bytes long")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// secrets returns the values of the secret fields of the configuration
+func (c *Container) secrets() map[string]string {
+	secrets := map[string]string{}
+	for _, field := range fields(c) {
+		if field.secret {
+			secrets[field.env] = format(field.value)
+		}
+	}
+
+	return secrets
+}
+
+// WatchSecrets loads the configuration again every period until ctx is done, calling onChange
+// with it whenever one of its secrets differs from the last configuration applied. A
+// configuration onChange fails to apply is offered again on the next period.
+func WatchSecrets(ctx context.Context, period time.Duration, current *Container, load func() (*Container, error), onChange func(*Container) error, logger *zap.SugaredLogger) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		fresh, err := load()
+		if err != nil {
+			logger.Warn("Error reloading secrets, keeping the current ones: ", err)
+			continue
+		}
+
+		if maps.Equal(fresh.secrets(), current.secrets()) {
+			continue
+		}
+
+		logger.Info("Secrets changed, applying them")
+		err = onChange(fresh)
+		if err != nil {
+			logger.Error("Error applying the new secrets, retrying on the next reload: ", err)
+			continue
+		}
+		current = fresh
+	}
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// newTestContainer returns an empty configuration whose database password is password
+func newTestContainer(password string) *Container {
+	return &Container{
+		App:     &App{},
+		Log:     &Log{},
+		Token:   &Token{},
+		Redis:   &Redis{},
+		DB:      &DB{Password: password},
+		HTTP:    &HTTP{},
+		Health:  &Health{},
+		Metrics: &Metrics{},
+		Admin:   &Admin{},
+		Tracing: &Tracing{},
+		Secrets: &Secrets{},
+	}
+}
+
+func TestSecretResolution(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	otherKey := make([]byte, 32)
+	rand.Read(otherKey)
+
+	tests := []struct {
+		name   string
+		env    map[string]string
+		dotenv string
+		// sections are loaded with Load, every section with New when nil
+		sections []string
+		want     string
+		err      string
+	}{
+		{
+			name: "file",
+			env:  map[string]string{"DB_PASSWORD_FILE": "db_password"},
+			want: "from-file",
+		},
+		{
+			name:   "file in .env",
+			dotenv: "DB_PASSWORD_FILE=db_password\n",
+			want:   "from-file",
+		},
+		{
+			name: "value and file",
+			env:  map[string]string{"DB_PASSWORD": "x", "DB_PASSWORD_FILE": "db_password"},
+			err:  "DB_PASSWORD and DB_PASSWORD_FILE are both set",
+		},
+		{
+			name: "missing file",
+			env:  map[string]string{"DB_PASSWORD_FILE": "missing"},
+			err:  "DB_PASSWORD_FILE: open missing",
+		},
+		{
+			name:     "missing file of a section not loaded",
+			env:      map[string]string{"DB_PASSWORD_FILE": "missing"},
+			sections: []string{"App"},
+		},
+		{
+			name: "encrypted file",
+			env:  map[string]string{"DB_PASSWORD": "secret:db_password", "SECRETS_KEY": base64.StdEncoding.EncodeToString(key)},
+			want: "from-provider",
+		},
+		{
+			name: "encrypted file key from a file",
+			env:  map[string]string{"DB_PASSWORD": "secret:db_password", "SECRETS_KEY_FILE": "secrets_key"},
+			want: "from-provider",
+		},
+		{
+			name: "unknown secret",
+			env:  map[string]string{"DB_PASSWORD": "secret:redis_password", "SECRETS_KEY": base64.StdEncoding.EncodeToString(key)},
+			err:  `DB_PASSWORD: secret "redis_password" not found`,
+		},
+		{
+			name: "wrong key",
+			env:  map[string]string{"DB_PASSWORD": "secret:db_password", "SECRETS_KEY": base64.StdEncoding.EncodeToString(otherKey)},
+			err:  "secrets file cannot be decrypted with SECRETS_KEY",
+		},
+		{
+			name: "short key",
+			env:  map[string]string{"DB_PASSWORD": "secret:db_password", "SECRETS_KEY": base64.StdEncoding.EncodeToString(key[:16])},
+			err:  "the secrets key must be 32 bytes long",
+		},
+		{
+			name: "unknown provider",
+			env:  map[string]string{"DB_PASSWORD": "secret:db_password", "SECRETS_PROVIDER": "vault"},
+			err:  `SECRETS_PROVIDER "vault" is not a known provider`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+
+			sealed, err := SealSecrets(key, map[string]string{"db_password": "from-provider"})
+			if err != nil {
+				t.Fatalf("SealSecrets: unexpected error: %v", err)
+			}
+			writeFile(t, "secrets", string(sealed))
+			writeFile(t, "secrets_key", base64.StdEncoding.EncodeToString(key)+"\n")
+			writeFile(t, "db_password", "from-file\n")
+			t.Setenv("SECRETS_FILE", "secrets")
+
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			if tt.dotenv != "" {
+				writeFile(t, dotenvFile, tt.dotenv)
+			}
+
+			var config *Container
+			if tt.sections == nil {
+				config, err = New(required...)
+			} else {
+				config, err = Load(tt.sections, required...)
+			}
+
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if config.DB.Password != tt.want {
+				t.Fatalf("got DB_PASSWORD %q, want %q", config.DB.Password, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchSecretsRetriesFailedChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	load := func() (*Container, error) {
+		return newTestContainer("rotated"), nil
+	}
+
+	//The first attempt to apply the rotated password fails, the second one succeeds
+	var applied []string
+	failures := 1
+	onChange := func(fresh *Container) error {
+		applied = append(applied, fresh.DB.Password)
+		if failures > 0 {
+			failures--
+			return errors.New("replica unreachable")
+		}
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		WatchSecrets(ctx, time.Millisecond, newTestContainer("initial"), load, onChange, zap.NewNop().Sugar())
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	if len(applied) != 2 || applied[0] != "rotated" || applied[1] != "rotated" {
+		t.Fatalf("got changes %q, want the rotated password offered until it is applied once", applied)
+	}
+}
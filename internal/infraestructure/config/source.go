@@ -0,0 +1,348 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go-clean-arch/internal/core/redact"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	dotenvFile = ".env"
+	// fileEnv names the configuration file when the --config flag is not given
+	fileEnv = "CONFIG_FILE"
+)
+
+// field is a configuration value and the metadata of its struct tags
+type field struct {
+	// section is the name of the Container field holding the value
+	section string
+	env     string
+	def     string
+	secret  bool
+	reload  bool
+	value   reflect.Value
+}
+
+// fields returns every configuration value of container, in declaration order
+func fields(container *Container) []field {
+	var fields []field
+
+	sections := reflect.ValueOf(container).Elem()
+	for i := range sections.NumField() {
+		if !sections.Type().Field(i).IsExported() {
+			continue
+		}
+
+		section := sections.Field(i).Elem()
+		for j := range section.NumField() {
+			tag := section.Type().Field(j).Tag
+			fields = append(fields, field{
+				section: sections.Type().Field(i).Name,
+				env:     tag.Get("env"),
+				def:     tag.Get("default"),
+				secret:  tag.Get("secret") == "true",
+				reload:  tag.Get("reload") == "true",
+				value:   section.Field(j),
+			})
+		}
+	}
+
+	return fields
+}
+
+// load merges the raw values of every source, later sources overriding earlier ones,
+// and returns them with the configuration file they were read from. Only the wanted
+// values are read from files and secret providers.
+func load(container *Container, args []string, wanted map[string]bool) (map[string]string, string, error) {
+	fields := fields(container)
+
+	known := map[string]bool{}
+	values := map[string]string{}
+	for _, field := range fields {
+		known[field.env] = true
+		if field.def != "" {
+			values[field.env] = field.def
+		}
+	}
+
+	flags, file, err := parseFlags(fields, args)
+	if err != nil {
+		return nil, "", err
+	}
+
+	dotenv, err := godotenv.Read(dotenvFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, "", fmt.Errorf("reading %s: %w", dotenvFile, err)
+	}
+
+	if file == "" {
+		file = os.Getenv(fileEnv)
+	}
+	if file == "" {
+		file = dotenv[fileEnv]
+	}
+
+	if file != "" {
+		fileValues, err := readFile(file, known)
+		if err != nil {
+			return nil, "", fmt.Errorf("reading %s: %w", file, err)
+		}
+		err = mergeSource(values, fileValues, known, wanted)
+		if err != nil {
+			return nil, "", fmt.Errorf("reading %s: %w", file, err)
+		}
+	}
+
+	err = mergeSource(values, dotenv, known, wanted)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading %s: %w", dotenvFile, err)
+	}
+
+	env := map[string]string{}
+	for key := range known {
+		for _, name := range []string{key, key + fileSuffix} {
+			if value, ok := os.LookupEnv(name); ok {
+				env[name] = value
+			}
+		}
+	}
+
+	err = mergeSource(values, env, known, wanted)
+	if err != nil {
+		return nil, "", err
+	}
+
+	merge(values, flags, known)
+
+	err = resolveSecrets(values, wanted)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return values, file, nil
+}
+
+// mergeSource copies the known values of src into dst, reading the wanted ones given as files
+func mergeSource(dst, src map[string]string, known, wanted map[string]bool) error {
+	src, err := readFiles(src, known, wanted)
+	if err != nil {
+		return err
+	}
+
+	merge(dst, src, known)
+	return nil
+}
+
+// merge copies the known values of src into dst
+func merge(dst, src map[string]string, known map[string]bool) {
+	for env, value := range src {
+		if known[env] {
+			dst[env] = value
+		}
+	}
+}
+
+// flagName returns the command line flag of the value read from env
+func flagName(env string) string {
+	return strings.ToLower(strings.ReplaceAll(env, "_", "-"))
+}
+
+// parseFlags returns the values set in args, keyed by their environment variable, and the configuration file
+func parseFlags(fields []field, args []string) (map[string]string, string, error) {
+	flags := flag.NewFlagSet("config", flag.ContinueOnError)
+	file := flags.String("config", "", "YAML or TOML configuration file, defaults to $"+fileEnv)
+
+	envs := map[string]string{}
+	for _, field := range fields {
+		name := flagName(field.env)
+		if _, ok := envs[name]; ok {
+			continue
+		}
+		envs[name] = field.env
+		flags.String(name, field.def, "overrides $"+field.env)
+	}
+
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, "", err
+	}
+	if flags.NArg() > 0 {
+		return nil, "", fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+
+	values := map[string]string{}
+	flags.Visit(func(f *flag.Flag) {
+		if env, ok := envs[f.Name]; ok {
+			values[env] = f.Value.String()
+		}
+	})
+
+	return values, *file, nil
+}
+
+// readFile returns the values of a YAML or TOML file. Its sections and keys are joined by an
+// underscore and upper cased to find the value they set, unknown ones are reported as errors.
+func readFile(path string, known map[string]bool) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree map[string]any
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &tree)
+	case ".toml":
+		err = toml.Unmarshal(content, &tree)
+	default:
+		err = fmt.Errorf("unsupported configuration file extension %q", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	flatten("", tree, values)
+
+	var errs []error
+	for env := range values {
+		if !known[env] {
+			errs = append(errs, fmt.Errorf("unknown key %s", strings.ToLower(env)))
+		}
+	}
+
+	return values, errors.Join(errs...)
+}
+
+func flatten(prefix string, value any, values map[string]string) {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			if prefix != "" {
+				key = prefix + "_" + key
+			}
+			flatten(strings.ToUpper(key), item, values)
+		}
+	case []any:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			items = append(items, fmt.Sprint(item))
+		}
+		values[prefix] = strings.Join(items, ",")
+	case nil:
+		values[prefix] = ""
+	default:
+		values[prefix] = fmt.Sprint(value)
+	}
+}
+
+// decode parses values into the fields of container, reporting the errors of the wanted ones
+func decode(container *Container, values map[string]string, wanted map[string]bool) error {
+	var errs []error
+
+	for _, field := range fields(container) {
+		value, ok := values[field.env]
+		if !ok {
+			continue
+		}
+
+		err := set(field.value, value)
+		if err != nil && wanted[field.env] {
+			errs = append(errs, fmt.Errorf("%s: invalid value %q: %w", field.env, value, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func set(v reflect.Value, value string) error {
+	if v.Type() == reflect.TypeFor[time.Duration]() {
+		if value == "" {
+			v.SetInt(0)
+			return nil
+		}
+
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		if value == "" {
+			v.SetInt(0)
+			return nil
+		}
+
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+// display returns the value of the field as it is printed, masked when it is a secret and mask is set
+func (f field) display(mask bool) string {
+	value := format(f.value)
+	if mask && f.secret && value != "" {
+		return redact.Placeholder
+	}
+
+	return value
+}
+
+// format returns the value of v as it is written in the environment
+func format(v reflect.Value) string {
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+
+	if items, ok := v.Interface().([]string); ok {
+		return strings.Join(items, ",")
+	}
+
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,132 @@
+// Package health runs the checks telling whether the application is ready to serve requests.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Checker reports why a dependency cannot be used, nil when it is healthy
+type Checker func(ctx context.Context) error
+
+// Health holds the checkers of the dependencies the application needs to serve requests
+type Health struct {
+	checks       []check
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+type check struct {
+	name     string
+	checker  Checker
+	optional bool
+}
+
+// Report is the outcome of every check, Status is up only when all the required ones are
+type Report struct {
+	Status string
+	Checks []Result
+}
+
+// Result is the outcome of a single check
+type Result struct {
+	Name     string
+	Status   string
+	Error    string
+	Optional bool
+	Duration time.Duration
+}
+
+// New returns a registry whose checks are each given at most timeout to complete
+func New(timeout time.Duration) *Health {
+	return &Health{
+		timeout: timeout,
+	}
+}
+
+// Register adds a check run on every readiness request
+func (h *Health) Register(name string, checker Checker) {
+	h.checks = append(h.checks, check{name, checker, false})
+}
+
+// RegisterOptional adds a check reported on every readiness request, the application
+// stays ready when it fails as it can serve requests without the dependency
+func (h *Health) RegisterOptional(name string, checker Checker) {
+	h.checks = append(h.checks, check{name, checker, true})
+}
+
+// Shutdown makes the application report itself as not ready from now on, so load balancers
+// stop routing requests to it while it drains the ones in flight
+func (h *Health) Shutdown() {
+	h.shuttingDown.Store(true)
+}
+
+// Ready runs every check concurrently, it is down during shutdown whatever their outcome
+func (h *Health) Ready(ctx context.Context) Report {
+	results := make([]Result, len(h.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = h.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{
+		Status: StatusUp,
+		Checks: results,
+	}
+	if h.shuttingDown.Load() {
+		report.Status = StatusDown
+	}
+	for _, result := range results {
+		if result.Status == StatusDown && !result.Optional {
+			report.Status = StatusDown
+		}
+	}
+
+	return report
+}
+
+func (h *Health) run(ctx context.Context, check check) Result {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	//A checker ignoring its ctx must not hold the report past the timeout
+	done := make(chan error, 1)
+	go func() {
+		done <- check.checker(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Result{
+		Name:     check.name,
+		Status:   StatusUp,
+		Optional: check.optional,
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,173 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Hook is a component of the application. OnStart must not block, long running work belongs to
+// a goroutine or a Worker, and OnStop must return once ctx is done. Both are optional.
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// Lifecycle starts the components of the application in the order they were appended, runs until
+// SIGINT, SIGTERM or a component failure and then stops them in reverse order
+type Lifecycle struct {
+	hooks  []Hook
+	failed chan error
+	logger *zap.SugaredLogger
+
+	// ctx is done on SIGINT or SIGTERM, handled from New on so the startup can be interrupted too
+	ctx     context.Context
+	release context.CancelFunc
+}
+
+func New(logger *zap.SugaredLogger) *Lifecycle {
+	ctx, release := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+
+	return &Lifecycle{
+		failed:  make(chan error, 1),
+		logger:  logger,
+		ctx:     ctx,
+		release: release,
+	}
+}
+
+// Context is done on SIGINT or SIGTERM, the work done while building the components, such as
+// connecting to the database, uses it to give up when the application is asked to stop
+func (l *Lifecycle) Context() context.Context {
+	return l.ctx
+}
+
+// Append adds a component, started after and stopped before the ones already appended
+func (l *Lifecycle) Append(hook Hook) {
+	l.hooks = append(l.hooks, hook)
+}
+
+// Fail stops the application because a component can no longer run
+func (l *Lifecycle) Fail(err error) {
+	select {
+	case l.failed <- err:
+	default:
+	}
+}
+
+// Worker returns the hook of a background job running fn until it is stopped, fn must return once its ctx is done
+func Worker(name string, fn func(ctx context.Context)) Hook {
+	var (
+		cancel context.CancelFunc
+		done   = make(chan struct{})
+	)
+
+	return Hook{
+		Name: name,
+		OnStart: func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go func() {
+				defer close(done)
+				fn(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// Run starts every component and blocks until the application is asked to stop, then stops them
+// within timeout. A component failing to start stops the ones started before it.
+func (l *Lifecycle) Run(timeout time.Duration) error {
+	ctx := l.ctx
+	defer l.release()
+
+	var err error
+	started := 0
+	for _, hook := range l.hooks {
+		if ctx.Err() != nil {
+			//Asked to stop while starting, the remaining components are not started
+			break
+		}
+		if hook.OnStart != nil {
+			l.logger.Debug("Starting ", hook.Name)
+			err = hook.OnStart(ctx)
+			if err != nil {
+				err = fmt.Errorf("starting %s: %w", hook.Name, err)
+				break
+			}
+		}
+		started++
+	}
+
+	if err == nil {
+		l.logger.Info("Application started")
+
+		select {
+		case <-ctx.Done():
+			l.logger.Info("Shutdown signal received, stopping the application")
+		case err = <-l.failed:
+			l.logger.Error("Stopping the application after a failure: ", err)
+		}
+	}
+
+	//Signals are no longer handled, a second one kills the process
+	l.release()
+
+	return errors.Join(err, l.stop(l.hooks[:started], timeout))
+}
+
+// Abort stops, within timeout, the components built before the application failed to reach Run or
+// was asked to stop while building them. Only the hooks without OnStart are stopped, as they run
+// from the moment they are appended, such as the database pools.
+func (l *Lifecycle) Abort(timeout time.Duration) error {
+	l.release()
+
+	built := slices.DeleteFunc(slices.Clone(l.hooks), func(hook Hook) bool {
+		return hook.OnStart != nil
+	})
+
+	return l.stop(built, timeout)
+}
+
+// stop stops hooks in reverse order, all of them within timeout
+func (l *Lifecycle) stop(hooks []Hook, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		if hook.OnStop == nil {
+			continue
+		}
+
+		l.logger.Debug("Stopping ", hook.Name)
+		err := hook.OnStop(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("stopping %s: %w", hook.Name, err))
+		}
+	}
+
+	if len(errs) == 0 {
+		l.logger.Info("Application stopped")
+	}
+
+	return errors.Join(errs...)
+}
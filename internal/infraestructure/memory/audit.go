@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"go-clean-arch/internal/adapter/repository"
+	"go-clean-arch/internal/core/domain"
+	"maps"
+	"sort"
+	"time"
+)
+
+var _ repository.AuditRepository = &Memory{}
+
+func (m *Memory) SaveAudit(ctx context.Context, log *domain.AuditLog) error {
+	unlock := m.lock(ctx)
+	defer unlock()
+
+	for _, other := range m.audit {
+		if other.ID == log.ID {
+			return domain.ErrConflictingData
+		}
+	}
+
+	saved := *log
+	saved.Changes = maps.Clone(log.Changes)
+	saved.CreatedAt = saved.CreatedAt.UTC().Truncate(time.Microsecond)
+	m.audit = append(m.audit, saved)
+
+	id := log.ID
+	m.onRollback(ctx, func() {
+		for i, other := range m.audit {
+			if other.ID == id {
+				m.audit = append(m.audit[:i], m.audit[i+1:]...)
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+func (m *Memory) ListAudit(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, error) {
+	unlock := m.rlock(ctx)
+	defer unlock()
+
+	var logs []domain.AuditLog
+	for _, log := range m.audit {
+		if filter.Actor != "" && log.Actor != filter.Actor {
+			continue
+		}
+		if filter.Action != "" && log.Action != filter.Action {
+			continue
+		}
+		if filter.TargetID != "" && log.TargetID != filter.TargetID {
+			continue
+		}
+		if !filter.From.IsZero() && log.CreatedAt.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && !log.CreatedAt.Before(filter.To) {
+			continue
+		}
+
+		log.Changes = maps.Clone(log.Changes)
+		logs = append(logs, log)
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		if !logs[i].CreatedAt.Equal(logs[j].CreatedAt) {
+			return logs[i].CreatedAt.After(logs[j].CreatedAt)
+		}
+		return logs[i].ID < logs[j].ID
+	})
+
+	return page(logs, filter.Skip, filter.Limit), nil
+}
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"go-clean-arch/internal/core/domain"
+	"sort"
+	"time"
+)
+
+// saveHistory appends the current state of user to the history, closing its
+// validity at validTo. The caller must hold the lock.
+func (m *Memory) saveHistory(ctx context.Context, user domain.User, operation string, validTo time.Time) {
+	validFrom := user.UpdatedAt
+	if validFrom.IsZero() {
+		validFrom = user.CreatedAt
+	}
+
+	m.seq++
+	m.history = append(m.history, userVersion{
+		UserVersion: domain.UserVersion{
+			User:      user,
+			Operation: operation,
+			ValidFrom: validFrom,
+			ValidTo:   validTo,
+		},
+		seq: m.seq,
+	})
+
+	seq := m.seq
+	m.onRollback(ctx, func() {
+		for i, version := range m.history {
+			if version.seq == seq {
+				m.history = append(m.history[:i], m.history[i+1:]...)
+				return
+			}
+		}
+	})
+}
+
+func (m *Memory) History(ctx context.Context, id string, skip, limit uint64) ([]domain.UserVersion, error) {
+	unlock := m.rlock(ctx)
+	defer unlock()
+
+	var matches []userVersion
+	for _, version := range m.history {
+		if version.ID == id {
+			matches = append(matches, version)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].ValidTo.Equal(matches[j].ValidTo) {
+			return matches[i].ValidTo.After(matches[j].ValidTo)
+		}
+		return matches[i].seq > matches[j].seq
+	})
+
+	var versions []domain.UserVersion
+	for _, version := range page(matches, skip, limit) {
+		versions = append(versions, version.UserVersion)
+	}
+
+	return versions, nil
+}
+
+// GetAsOf returns the user as it was at asOf: the history row valid at that
+// time or, when none is, the current row if it was already in place.
+func (m *Memory) GetAsOf(ctx context.Context, id string, asOf time.Time) (*domain.User, error) {
+	unlock := m.rlock(ctx)
+	defer unlock()
+
+	var found *userVersion
+	for i, version := range m.history {
+		if version.ID != id || version.ValidFrom.After(asOf) || !version.ValidTo.After(asOf) {
+			continue
+		}
+		if found == nil || version.ValidTo.Before(found.ValidTo) {
+			found = &m.history[i]
+		}
+	}
+
+	if found != nil {
+		user := found.User
+		return &user, nil
+	}
+
+	user, ok := m.users[id]
+	if !ok {
+		return nil, domain.ErrDataNotFound
+	}
+
+	validFrom := user.UpdatedAt
+	if validFrom.IsZero() {
+		validFrom = user.CreatedAt
+	}
+	if validFrom.After(asOf) {
+		return nil, domain.ErrDataNotFound
+	}
+
+	return &user, nil
+}
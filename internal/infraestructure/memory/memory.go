@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"go-clean-arch/internal/adapter/repository"
+	"go-clean-arch/internal/core/domain"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var _ repository.Transactor = &Memory{}
+
+// Memory is a concurrency-safe in-memory database reproducing the behaviour of the
+// postgres adapter. Transactions are serialized: one holds the lock until it ends,
+// and its changes are undone if it fails.
+type Memory struct {
+	mu      sync.RWMutex
+	users   map[string]domain.User
+	history []userVersion
+	audit   []domain.AuditLog
+	seq     int64
+	zap.SugaredLogger
+}
+
+// userVersion is a row of the user history, seq keeps the insertion order
+type userVersion struct {
+	domain.UserVersion
+	seq int64
+}
+
+type txKey struct{}
+
+// tx holds the undo log of a running transaction
+type tx struct {
+	undo []func()
+}
+
+func NewDatabase(logger *zap.SugaredLogger) *Memory {
+	logger.Info("Successfully created the in-memory database")
+
+	return &Memory{
+		users:         map[string]domain.User{},
+		SugaredLogger: *logger,
+	}
+}
+
+func (m *Memory) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*tx); ok {
+		return fn(ctx)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := &tx{}
+	err := fn(context.WithValue(ctx, txKey{}, t))
+	if err != nil {
+		for i := len(t.undo) - 1; i >= 0; i-- {
+			t.undo[i]()
+		}
+		return err
+	}
+
+	return nil
+}
+
+// lock acquires the write lock unless ctx belongs to a transaction, which already holds it
+func (m *Memory) lock(ctx context.Context) func() {
+	if _, ok := ctx.Value(txKey{}).(*tx); ok {
+		return func() {}
+	}
+
+	m.mu.Lock()
+	return m.mu.Unlock
+}
+
+// rlock acquires the read lock unless ctx belongs to a transaction, which already holds the write lock
+func (m *Memory) rlock(ctx context.Context) func() {
+	if _, ok := ctx.Value(txKey{}).(*tx); ok {
+		return func() {}
+	}
+
+	m.mu.RLock()
+	return m.mu.RUnlock
+}
+
+// onRollback registers fn to be run if the transaction of ctx fails
+func (m *Memory) onRollback(ctx context.Context, fn func()) {
+	if t, ok := ctx.Value(txKey{}).(*tx); ok {
+		t.undo = append(t.undo, fn)
+	}
+}
+
+// now returns the current time with the precision and location of a postgres TIMESTAMP
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// page returns the page of items selected by skip and limit, the same way as
+// the postgres adapter does with OFFSET skip * limit LIMIT limit
+func page[T any](items []T, skip, limit uint64) []T {
+	offset := skip * limit
+	if offset >= uint64(len(items)) {
+		return nil
+	}
+
+	end := offset + limit
+	if end > uint64(len(items)) {
+		end = uint64(len(items))
+	}
+	if offset == end {
+		return nil
+	}
+
+	return items[offset:end]
+}
@@ -0,0 +1,15 @@
+package memory
+
+import (
+	"go-clean-arch/internal/adapter/repository"
+	"go-clean-arch/internal/adapter/repository/repositorytest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestUserRepositoryContract(t *testing.T) {
+	repositorytest.TestUserRepository(t, func(t *testing.T) repository.UserRepository {
+		return NewDatabase(zap.NewNop().Sugar())
+	})
+}
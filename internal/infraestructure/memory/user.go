@@ -0,0 +1,177 @@
+package memory
+
+import (
+	"context"
+	"go-clean-arch/internal/adapter/repository"
+	"go-clean-arch/internal/core/domain"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var _ repository.UserRepository = &Memory{}
+
+func (m *Memory) Save(ctx context.Context, user *domain.User) error {
+	unlock := m.lock(ctx)
+	defer unlock()
+
+	err := invalid(user)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := m.users[user.ID]; ok {
+		return conflict("id")
+	}
+
+	err = m.conflicting(user)
+	if err != nil {
+		return err
+	}
+
+	user.CreatedAt = now()
+	user.UpdatedAt = user.CreatedAt
+
+	id := user.ID
+	m.users[id] = *user
+	m.onRollback(ctx, func() { delete(m.users, id) })
+
+	return nil
+}
+
+func (m *Memory) Get(ctx context.Context, id string) (*domain.User, error) {
+	unlock := m.rlock(ctx)
+	defer unlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		return nil, domain.ErrDataNotFound
+	}
+
+	return &user, nil
+}
+
+// GetForUpdate is Get, a transaction holds the write lock so the user cannot change meanwhile
+func (m *Memory) GetForUpdate(ctx context.Context, id string) (*domain.User, error) {
+	return m.Get(ctx, id)
+}
+
+func (m *Memory) List(ctx context.Context, skip, limit uint64) ([]domain.User, error) {
+	unlock := m.rlock(ctx)
+	defer unlock()
+
+	users := make([]domain.User, 0, len(m.users))
+	for _, user := range m.users {
+		users = append(users, user)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	return page(users, skip, limit), nil
+}
+
+func (m *Memory) Update(ctx context.Context, user *domain.User) error {
+	unlock := m.lock(ctx)
+	defer unlock()
+
+	err := invalid(user)
+	if err != nil {
+		return err
+	}
+
+	old, ok := m.users[user.ID]
+	if !ok {
+		return domain.ErrDataNotFound
+	}
+
+	err = m.conflicting(user)
+	if err != nil {
+		return err
+	}
+
+	updated := *user
+	updated.CreatedAt = old.CreatedAt
+	updated.UpdatedAt = now()
+
+	m.saveHistory(ctx, old, domain.UserOperationUpdate, updated.UpdatedAt)
+
+	m.users[user.ID] = updated
+	m.onRollback(ctx, func() { m.users[old.ID] = old })
+
+	*user = updated
+
+	return nil
+}
+
+func (m *Memory) Delete(ctx context.Context, id string) error {
+	unlock := m.lock(ctx)
+	defer unlock()
+
+	old, ok := m.users[id]
+	if !ok {
+		return domain.ErrDataNotFound
+	}
+
+	m.saveHistory(ctx, old, domain.UserOperationDelete, now())
+
+	delete(m.users, id)
+	m.onRollback(ctx, func() { m.users[old.ID] = old })
+
+	return nil
+}
+
+// conflicting returns the error of the unique field of user another user already holds,
+// the caller must hold the lock
+func (m *Memory) conflicting(user *domain.User) error {
+	for id, other := range m.users {
+		if id == user.ID {
+			continue
+		}
+		if other.Document == user.Document {
+			return conflict("document")
+		}
+		if strings.EqualFold(other.Email, user.Email) {
+			return conflict("email")
+		}
+	}
+
+	return nil
+}
+
+// invalid returns the error of the Postgres adapter for a user its schema rejects: an age out of
+// the range of user_age_check, or a value longer than its VARCHAR column, which Postgres reports
+// without naming the column
+func invalid(user *domain.User) error {
+	if user.Age < 0 || user.Age > 150 {
+		return domain.ErrInvalidData.
+			WithMessage("age is invalid").
+			WithDetails(domain.ErrorDetail{Field: "age", Message: "is invalid"})
+	}
+
+	columns := []struct {
+		value string
+		size  int
+	}{
+		{user.ID, 64},
+		{user.Document, 20},
+		{user.Name, 255},
+		{user.Email, 254},
+		{user.Password, 72},
+	}
+	for _, column := range columns {
+		if utf8.RuneCountInString(column.value) > column.size {
+			return domain.ErrInvalidData
+		}
+	}
+
+	return nil
+}
+
+// conflict returns ErrConflictingData for field, worded like the Postgres adapter does
+func conflict(field string) error {
+	return domain.ErrConflictingData.
+		WithMessage(field + " already in use").
+		WithDetails(domain.ErrorDetail{Field: field, Message: "already in use"})
+}
@@ -0,0 +1,87 @@
+// Package metrics exposes the Prometheus metrics of the application on their own HTTP server.
+package metrics
+
+import (
+	"go-clean-arch/internal/core/domain"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// outcomeOK is the outcome label of the operations that returned no error, the others are labeled with their domain error code
+const outcomeOK = "ok"
+
+// Registry holds every metric of the application, along with the Go runtime and process ones
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests handled, by route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time taken to handle HTTP requests, by route template.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	HTTPRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "HTTP requests being handled.",
+	})
+
+	useCaseOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "usecase_operations_total",
+		Help: "Use case operations, by outcome: ok or the domain error code.",
+	}, []string{"usecase", "operation", "outcome"})
+
+	useCaseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "usecase_operation_duration_seconds",
+		Help:    "Time taken by use case operations.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"usecase", "operation"})
+
+	repositoryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "repository_query_duration_seconds",
+		Help:    "Time taken by repository methods, by outcome: ok or the domain error code.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"repository", "method", "outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		useCaseOperations,
+		useCaseDuration,
+		repositoryDuration,
+	)
+}
+
+// NewServer returns the server exposing the metrics on /metrics at listenAddr
+func NewServer(listenAddr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry}))
+
+	return &http.Server{
+		Addr:              listenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
+
+// outcome returns the label of the result of an operation
+func outcome(err error) string {
+	if err == nil {
+		return outcomeOK
+	}
+
+	return string(domain.AsError(err).Code)
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"go-clean-arch/internal/infraestructure/postgres"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector reads the statistics of a pgx pool when the metrics are scraped
+type poolCollector struct {
+	stats func() postgres.PoolStats
+
+	maxConns         *prometheus.Desc
+	conns            *prometheus.Desc
+	acquires         *prometheus.Desc
+	emptyAcquires    *prometheus.Desc
+	canceledAcquires *prometheus.Desc
+	acquireDuration  *prometheus.Desc
+	newConns         *prometheus.Desc
+	destroyedConns   *prometheus.Desc
+}
+
+// RegisterPool exports the statistics of the Postgres connection pool
+func RegisterPool(stats func() postgres.PoolStats) {
+	Registry.MustRegister(&poolCollector{
+		stats:            stats,
+		maxConns:         prometheus.NewDesc("db_pool_max_conns", "Maximum size of the connection pool.", nil, nil),
+		conns:            prometheus.NewDesc("db_pool_conns", "Connections of the pool, by state.", []string{"state"}, nil),
+		acquires:         prometheus.NewDesc("db_pool_acquires_total", "Connections acquired from the pool.", nil, nil),
+		emptyAcquires:    prometheus.NewDesc("db_pool_empty_acquires_total", "Acquires that waited for a connection as the pool was empty.", nil, nil),
+		canceledAcquires: prometheus.NewDesc("db_pool_canceled_acquires_total", "Acquires canceled by their context.", nil, nil),
+		acquireDuration:  prometheus.NewDesc("db_pool_acquire_duration_seconds_total", "Time spent acquiring connections.", nil, nil),
+		newConns:         prometheus.NewDesc("db_pool_new_conns_total", "Connections opened by the pool.", nil, nil),
+		destroyedConns:   prometheus.NewDesc("db_pool_destroyed_conns_total", "Connections closed by the pool, by reason.", []string{"reason"}, nil),
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxConns
+	ch <- c.conns
+	ch <- c.acquires
+	ch <- c.emptyAcquires
+	ch <- c.canceledAcquires
+	ch <- c.acquireDuration
+	ch <- c.newConns
+	ch <- c.destroyedConns
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stats.MaxConns))
+	ch <- prometheus.MustNewConstMetric(c.conns, prometheus.GaugeValue, float64(stats.AcquiredConns), "acquired")
+	ch <- prometheus.MustNewConstMetric(c.conns, prometheus.GaugeValue, float64(stats.IdleConns), "idle")
+	ch <- prometheus.MustNewConstMetric(c.conns, prometheus.GaugeValue, float64(stats.ConstructingConns), "constructing")
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(stats.AcquireCount))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquires, prometheus.CounterValue, float64(stats.EmptyAcquireCount))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquires, prometheus.CounterValue, float64(stats.CanceledAcquireCount))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stats.AcquireDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.newConns, prometheus.CounterValue, float64(stats.NewConnsCount))
+	ch <- prometheus.MustNewConstMetric(c.destroyedConns, prometheus.CounterValue, float64(stats.MaxLifetimeDestroyCount), "max_lifetime")
+	ch <- prometheus.MustNewConstMetric(c.destroyedConns, prometheus.CounterValue, float64(stats.MaxIdleDestroyCount), "max_idle")
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"context"
+	"go-clean-arch/internal/adapter/repository"
+	"go-clean-arch/internal/core/domain"
+	"time"
+)
+
+var _ repository.Database = &Database{}
+
+// Database times every method of the wrapped database adapter
+type Database struct {
+	next repository.Database
+	name string
+}
+
+// NewDatabase wraps next, its durations are labeled with name, the DB_CONNECTION it was built for
+func NewDatabase(next repository.Database, name string) *Database {
+	return &Database{
+		next,
+		name,
+	}
+}
+
+// observe records the duration of a repository method started at start
+func (d *Database) observe(method string, start time.Time, err error) {
+	repositoryDuration.WithLabelValues(d.name, method, outcome(err)).Observe(time.Since(start).Seconds())
+}
+
+func (d *Database) Save(ctx context.Context, user *domain.User) error {
+	start := time.Now()
+	err := d.next.Save(ctx, user)
+	d.observe("save", start, err)
+	return err
+}
+
+func (d *Database) List(ctx context.Context, skip, limit uint64) ([]domain.User, error) {
+	start := time.Now()
+	users, err := d.next.List(ctx, skip, limit)
+	d.observe("list", start, err)
+	return users, err
+}
+
+func (d *Database) Get(ctx context.Context, id string) (*domain.User, error) {
+	start := time.Now()
+	user, err := d.next.Get(ctx, id)
+	d.observe("get", start, err)
+	return user, err
+}
+
+func (d *Database) GetForUpdate(ctx context.Context, id string) (*domain.User, error) {
+	start := time.Now()
+	user, err := d.next.GetForUpdate(ctx, id)
+	d.observe("get_for_update", start, err)
+	return user, err
+}
+
+func (d *Database) Update(ctx context.Context, user *domain.User) error {
+	start := time.Now()
+	err := d.next.Update(ctx, user)
+	d.observe("update", start, err)
+	return err
+}
+
+func (d *Database) Delete(ctx context.Context, id string) error {
+	start := time.Now()
+	err := d.next.Delete(ctx, id)
+	d.observe("delete", start, err)
+	return err
+}
+
+func (d *Database) History(ctx context.Context, id string, skip, limit uint64) ([]domain.UserVersion, error) {
+	start := time.Now()
+	versions, err := d.next.History(ctx, id, skip, limit)
+	d.observe("history", start, err)
+	return versions, err
+}
+
+func (d *Database) GetAsOf(ctx context.Context, id string, asOf time.Time) (*domain.User, error) {
+	start := time.Now()
+	user, err := d.next.GetAsOf(ctx, id, asOf)
+	d.observe("get_as_of", start, err)
+	return user, err
+}
+
+func (d *Database) SaveAudit(ctx context.Context, log *domain.AuditLog) error {
+	start := time.Now()
+	err := d.next.SaveAudit(ctx, log)
+	d.observe("save_audit", start, err)
+	return err
+}
+
+func (d *Database) ListAudit(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, error) {
+	start := time.Now()
+	logs, err := d.next.ListAudit(ctx, filter)
+	d.observe("list_audit", start, err)
+	return logs, err
+}
+
+// WithinTransaction is timed as a whole, the methods called inside it are timed on their own
+func (d *Database) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	start := time.Now()
+	err := d.next.WithinTransaction(ctx, fn)
+	d.observe("transaction", start, err)
+	return err
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"context"
+	"go-clean-arch/internal/core/domain"
+	"go-clean-arch/internal/core/usecase"
+	"time"
+)
+
+var _ usecase.UserUseCase = &UserUseCase{}
+var _ usecase.AuditUseCase = &AuditUseCase{}
+
+// observe records the outcome and duration of a use case operation started at start
+func observe(name, operation string, start time.Time, err error) {
+	useCaseOperations.WithLabelValues(name, operation, outcome(err)).Inc()
+	useCaseDuration.WithLabelValues(name, operation).Observe(time.Since(start).Seconds())
+}
+
+// UserUseCase counts the operations of the wrapped use case by outcome
+type UserUseCase struct {
+	next usecase.UserUseCase
+}
+
+func NewUserUseCase(next usecase.UserUseCase) *UserUseCase {
+	return &UserUseCase{
+		next,
+	}
+}
+
+func (u *UserUseCase) CreateUser(ctx context.Context, user *domain.User) error {
+	start := time.Now()
+	err := u.next.CreateUser(ctx, user)
+	observe("user", "create", start, err)
+	return err
+}
+
+func (u *UserUseCase) GetUser(ctx context.Context, id string) (*domain.User, error) {
+	start := time.Now()
+	user, err := u.next.GetUser(ctx, id)
+	observe("user", "get", start, err)
+	return user, err
+}
+
+func (u *UserUseCase) ListUsers(ctx context.Context, skip, limit uint64) ([]domain.User, error) {
+	start := time.Now()
+	users, err := u.next.ListUsers(ctx, skip, limit)
+	observe("user", "list", start, err)
+	return users, err
+}
+
+func (u *UserUseCase) UpdateUser(ctx context.Context, user *domain.User) error {
+	start := time.Now()
+	err := u.next.UpdateUser(ctx, user)
+	observe("user", "update", start, err)
+	return err
+}
+
+func (u *UserUseCase) DeleteUser(ctx context.Context, id string) error {
+	start := time.Now()
+	err := u.next.DeleteUser(ctx, id)
+	observe("user", "delete", start, err)
+	return err
+}
+
+func (u *UserUseCase) GetUserHistory(ctx context.Context, id string, skip, limit uint64) ([]domain.UserVersion, error) {
+	start := time.Now()
+	versions, err := u.next.GetUserHistory(ctx, id, skip, limit)
+	observe("user", "history", start, err)
+	return versions, err
+}
+
+func (u *UserUseCase) GetUserAsOf(ctx context.Context, id string, asOf time.Time) (*domain.User, error) {
+	start := time.Now()
+	user, err := u.next.GetUserAsOf(ctx, id, asOf)
+	observe("user", "get_as_of", start, err)
+	return user, err
+}
+
+// AuditUseCase counts the operations of the wrapped use case by outcome
+type AuditUseCase struct {
+	next usecase.AuditUseCase
+}
+
+func NewAuditUseCase(next usecase.AuditUseCase) *AuditUseCase {
+	return &AuditUseCase{
+		next,
+	}
+}
+
+func (a *AuditUseCase) ListAuditLogs(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, error) {
+	start := time.Now()
+	logs, err := a.next.ListAuditLogs(ctx, filter)
+	observe("audit", "list", start, err)
+	return logs, err
+}
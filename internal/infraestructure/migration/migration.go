@@ -0,0 +1,101 @@
+// Package migration holds the schema checks shared by the database adapters running golang-migrate.
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// ErrSchemaAhead is returned when the database was migrated by a newer binary
+var ErrSchemaAhead = errors.New("database schema is ahead of the binary")
+
+// ErrSchemaDirty is returned when a previous migration failed halfway
+var ErrSchemaDirty = errors.New("database schema is dirty")
+
+// ErrSchemaBehind is returned when migrations known to the binary were not applied yet
+var ErrSchemaBehind = errors.New("database schema is behind the binary")
+
+// Source returns a golang-migrate source reading the migrations under path in fsys
+func Source(fsys fs.FS, path string) (source.Driver, error) {
+	return iofs.New(fsys, path)
+}
+
+// Latest returns the highest migration version found under path in fsys
+func Latest(fsys fs.FS, path string) (uint, error) {
+	src, err := Source(fsys, path)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
+
+// Check refuses a schema that is dirty or newer than latest, an empty schema is fine
+func Check(m *migrate.Migrate, latest uint) error {
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("%w at version %d, fix it and force the version with the migration CLI", ErrSchemaDirty, version)
+	}
+
+	if version > latest {
+		return fmt.Errorf("%w: schema version %d, latest known migration %d", ErrSchemaAhead, version, latest)
+	}
+
+	return nil
+}
+
+// Up checks the schema and applies the pending migrations
+func Up(m *migrate.Migrate, latest uint) error {
+	err := Check(m, latest)
+	if err != nil {
+		return err
+	}
+
+	err = m.Up()
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	return nil
+}
+
+// Matches reports whether a schema at version, as golang-migrate records it, is exactly the latest one
+func Matches(version uint, dirty bool, latest uint) error {
+	switch {
+	case dirty:
+		return fmt.Errorf("%w at version %d", ErrSchemaDirty, version)
+	case version > latest:
+		return fmt.Errorf("%w: schema version %d, latest known migration %d", ErrSchemaAhead, version, latest)
+	case version < latest:
+		return fmt.Errorf("%w: schema version %d, latest known migration %d", ErrSchemaBehind, version, latest)
+	}
+
+	return nil
+}
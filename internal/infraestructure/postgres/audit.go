@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"go-clean-arch/internal/adapter/repository"
+	"go-clean-arch/internal/core/domain"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+var _ repository.AuditRepository = &Postgres{}
+
+func (pg *Postgres) SaveAudit(ctx context.Context, log *domain.AuditLog) error {
+	repository.MarkWritten(ctx)
+
+	query := pg.db.QueryBuilder.Insert("public.audit_log").
+		Columns("id", "actor", "actor_verified", "action", "target_type", "target_id", "changes", "request_id", "client_ip", "created_at").
+		Values(log.ID, log.Actor, log.ActorVerified, log.Action, log.TargetType, log.TargetID, log.Changes, log.RequestID, log.ClientIP, log.CreatedAt)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = pg.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (pg *Postgres) ListAudit(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, error) {
+	var log domain.AuditLog
+	var logs []domain.AuditLog
+
+	query := pg.db.QueryBuilder.Select("id", "actor", "actor_verified", "action", "target_type", "target_id", "changes", "request_id", "client_ip", "created_at").
+		From("public.audit_log").
+		OrderBy("created_at DESC", "id").
+		Limit(filter.Limit).
+		Offset(filter.Skip * filter.Limit)
+
+	if filter.Actor != "" {
+		query = query.Where(sq.Eq{"actor": filter.Actor})
+	}
+	if filter.Action != "" {
+		query = query.Where(sq.Eq{"action": filter.Action})
+	}
+	if filter.TargetID != "" {
+		query = query.Where(sq.Eq{"target_id": filter.TargetID})
+	}
+	if !filter.From.IsZero() {
+		query = query.Where(sq.GtOrEq{"created_at": filter.From})
+	}
+	if !filter.To.IsZero() {
+		query = query.Where(sq.Lt{"created_at": filter.To})
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pg.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		log.Changes = nil
+		err := rows.Scan(
+			&log.ID,
+			&log.Actor,
+			&log.ActorVerified,
+			&log.Action,
+			&log.TargetType,
+			&log.TargetID,
+			&log.Changes,
+			&log.RequestID,
+			&log.ClientIP,
+			&log.CreatedAt,
+		)
+		if err != nil {
+			return nil, translateError(err)
+		}
+
+		logs = append(logs, log)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return logs, nil
+}
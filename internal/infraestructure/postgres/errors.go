@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"errors"
+	"go-clean-arch/internal/core/domain"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// constraintFields maps the constraints of the schema to the field they guard
+var constraintFields = map[string]string{
+	"user_pk":              "id",
+	"user_unique_document": "document",
+	"user_unique_email":    "email",
+	"user_age_check":       "age",
+	"audit_log_pk":         "id",
+}
+
+// translateError maps the errors reported by Postgres to domain errors, keeping the original
+// one as cause. Errors it does not know about are returned untouched.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrDataNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		if pgconn.Timeout(err) {
+			return domain.ErrUnavailable.Wrap(err)
+		}
+		return err
+	}
+
+	switch {
+	case pgErr.Code == pgerrcode.UniqueViolation, pgErr.Code == pgerrcode.ExclusionViolation:
+		return constraintError(domain.ErrConflictingData, "already in use", pgErr)
+	case pgerrcode.IsIntegrityConstraintViolation(pgErr.Code), pgerrcode.IsDataException(pgErr.Code):
+		return constraintError(domain.ErrInvalidData, "is invalid", pgErr)
+	case pgErr.Code == pgerrcode.SerializationFailure, pgErr.Code == pgerrcode.DeadlockDetected:
+		return domain.ErrConcurrentUpdate.Wrap(err)
+	case pgerrcode.IsConnectionException(pgErr.Code),
+		pgerrcode.IsInsufficientResources(pgErr.Code),
+		pgerrcode.IsOperatorIntervention(pgErr.Code):
+		return domain.ErrUnavailable.Wrap(err)
+	}
+
+	return err
+}
+
+// constraintError refines sentinel with the field pgErr is about, when it is known
+func constraintError(sentinel *domain.Error, problem string, pgErr *pgconn.PgError) error {
+	field, ok := constraintFields[pgErr.ConstraintName]
+	if !ok {
+		field = pgErr.ColumnName
+	}
+
+	if field == "" {
+		return sentinel.Wrap(pgErr)
+	}
+
+	return sentinel.
+		WithMessage(field + " " + problem).
+		WithDetails(domain.ErrorDetail{Field: field, Message: problem}).
+		Wrap(pgErr)
+}
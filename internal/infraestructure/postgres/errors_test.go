@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"go-clean-arch/internal/core/domain"
+	"testing"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestTranslateError(t *testing.T) {
+	other := errors.New("something else")
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+		// field is the one the translated error details, empty when it names none
+		field string
+	}{
+		{name: "nil", err: nil, want: nil},
+		{name: "no rows", err: pgx.ErrNoRows, want: domain.ErrDataNotFound},
+		{name: "wrapped no rows", err: fmt.Errorf("scan: %w", pgx.ErrNoRows), want: domain.ErrDataNotFound},
+		{
+			name:  "unique email",
+			err:   &pgconn.PgError{Code: pgerrcode.UniqueViolation, ConstraintName: "user_unique_email"},
+			want:  domain.ErrConflictingData,
+			field: "email",
+		},
+		{
+			name:  "primary key",
+			err:   &pgconn.PgError{Code: pgerrcode.UniqueViolation, ConstraintName: "user_pk"},
+			want:  domain.ErrConflictingData,
+			field: "id",
+		},
+		{
+			name: "unknown unique constraint",
+			err:  &pgconn.PgError{Code: pgerrcode.UniqueViolation, ConstraintName: "other_unique"},
+			want: domain.ErrConflictingData,
+		},
+		{
+			name: "exclusion",
+			err:  &pgconn.PgError{Code: pgerrcode.ExclusionViolation},
+			want: domain.ErrConflictingData,
+		},
+		{
+			name:  "age check",
+			err:   &pgconn.PgError{Code: pgerrcode.CheckViolation, ConstraintName: "user_age_check"},
+			want:  domain.ErrInvalidData,
+			field: "age",
+		},
+		{
+			name:  "not null column",
+			err:   &pgconn.PgError{Code: pgerrcode.NotNullViolation, ColumnName: "name"},
+			want:  domain.ErrInvalidData,
+			field: "name",
+		},
+		{
+			name: "value too long",
+			err:  &pgconn.PgError{Code: pgerrcode.StringDataRightTruncationDataException},
+			want: domain.ErrInvalidData,
+		},
+		{name: "serialization failure", err: &pgconn.PgError{Code: pgerrcode.SerializationFailure}, want: domain.ErrConcurrentUpdate},
+		{name: "deadlock", err: &pgconn.PgError{Code: pgerrcode.DeadlockDetected}, want: domain.ErrConcurrentUpdate},
+		{name: "connection failure", err: &pgconn.PgError{Code: pgerrcode.ConnectionFailure}, want: domain.ErrUnavailable},
+		{name: "too many connections", err: &pgconn.PgError{Code: pgerrcode.TooManyConnections}, want: domain.ErrUnavailable},
+		{name: "admin shutdown", err: &pgconn.PgError{Code: pgerrcode.AdminShutdown}, want: domain.ErrUnavailable},
+		{name: "syntax error", err: &pgconn.PgError{Code: pgerrcode.SyntaxError}},
+		{name: "unknown error", err: other, want: other},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := translateError(tt.err)
+
+			want := tt.want
+			if want == nil {
+				want = tt.err
+			}
+			if !errors.Is(got, want) {
+				t.Fatalf("translateError(%v) = %v, want %v", tt.err, got, want)
+			}
+			//Not found is the only translation that drops the cause
+			if tt.err != nil && !errors.Is(tt.err, pgx.ErrNoRows) && !errors.Is(got, tt.err) {
+				t.Fatalf("translateError(%v) = %v, lost the original error", tt.err, got)
+			}
+
+			if tt.want == nil {
+				return
+			}
+			details := domain.AsError(got).Details
+			if tt.field == "" && len(details) != 0 {
+				t.Fatalf("translateError(%v) details %+v, want none", tt.err, details)
+			}
+			if tt.field != "" && (len(details) != 1 || details[0].Field != tt.field) {
+				t.Fatalf("translateError(%v) details %+v, want field %s", tt.err, details, tt.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"go-clean-arch/internal/core/domain"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// saveHistory copies the current row of the user into user_history, closing its
+// validity at validTo. It must run in the same transaction as the change itself.
+func (pg *Postgres) saveHistory(ctx context.Context, id, operation string, validTo time.Time) error {
+	current := pg.db.QueryBuilder.Select(userColumns...).
+		Column("?::varchar", operation).
+		Column("COALESCE(updated_at, created_at)").
+		Column("?::timestamptz", validTo).
+		From("public.user").
+		Where(sq.Eq{"id": id}).
+		Suffix("FOR UPDATE")
+
+	query := pg.db.QueryBuilder.Insert("public.user_history").
+		Columns(append(userColumns, "operation", "valid_from", "valid_to")...).
+		Select(current)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	tag, err := pg.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		return translateError(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return domain.ErrDataNotFound
+	}
+
+	return nil
+}
+
+func (pg *Postgres) History(ctx context.Context, id string, skip, limit uint64) ([]domain.UserVersion, error) {
+	var version domain.UserVersion
+	var versions []domain.UserVersion
+
+	query := pg.db.QueryBuilder.Select(userColumns...).
+		Columns("operation", "valid_from", "valid_to").
+		From("public.user_history").
+		Where(sq.Eq{"id": id}).
+		OrderBy("valid_to DESC", "history_id DESC").
+		Limit(limit).
+		Offset(skip * limit)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pg.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err := rows.Scan(
+			&version.ID,
+			&version.Document,
+			&version.Name,
+			&version.Email,
+			&version.Age,
+			&version.Password,
+			&version.CreatedAt,
+			&version.UpdatedAt,
+			&version.Operation,
+			&version.ValidFrom,
+			&version.ValidTo,
+		)
+		if err != nil {
+			return nil, translateError(err)
+		}
+
+		versions = append(versions, version)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return versions, nil
+}
+
+// GetAsOf returns the user as it was at asOf: the history row valid at that
+// time or, when none is, the current row if it was already in place.
+func (pg *Postgres) GetAsOf(ctx context.Context, id string, asOf time.Time) (*domain.User, error) {
+	past := pg.db.QueryBuilder.Select(userColumns...).
+		From("public.user_history").
+		Where(sq.Eq{"id": id}).
+		Where(sq.LtOrEq{"valid_from": asOf}).
+		Where(sq.Gt{"valid_to": asOf}).
+		OrderBy("valid_to").
+		Limit(1)
+
+	user, err := pg.getUser(ctx, past)
+	if !errors.Is(err, domain.ErrDataNotFound) {
+		return user, err
+	}
+
+	current := pg.db.QueryBuilder.Select(userColumns...).
+		From("public.user").
+		Where(sq.Eq{"id": id}).
+		Where(sq.LtOrEq{"COALESCE(updated_at, created_at)": asOf}).
+		Limit(1)
+
+	return pg.getUser(ctx, current)
+}
+
+func (pg *Postgres) getUser(ctx context.Context, query sq.SelectBuilder) (*domain.User, error) {
+	var user domain.User
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	err = pg.conn(ctx).QueryRow(ctx, sql, args...).Scan(
+		&user.ID,
+		&user.Document,
+		&user.Name,
+		&user.Email,
+		&user.Age,
+		&user.Password,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"go-clean-arch/internal/infraestructure/config"
+	"go-clean-arch/internal/infraestructure/migration"
+	"go-clean-arch/migrations"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// migrationLockID is the key of the advisory lock held while migrating, so
+// replicas starting together apply the migrations one at a time
+const migrationLockID int64 = 7263517401
+
+// Migrate refuses a schema that is dirty or newer than the embedded migrations.
+// With config.AutoMigrate it applies the pending migrations first.
+func Migrate(ctx context.Context, config *config.DB, logger *zap.SugaredLogger) error {
+	latest, err := migration.Latest(migrations.FS, ".")
+	if err != nil {
+		return err
+	}
+
+	src, err := migration.Source(migrations.FS, ".")
+	if err != nil {
+		return err
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, URL(config))
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if !config.AutoMigrate {
+		return migration.Check(m, latest)
+	}
+
+	conn, err := pgx.Connect(ctx, URL(config))
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	logger.Info("Waiting for the migration lock")
+
+	_, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockID)
+	if err != nil {
+		return err
+	}
+	defer conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	logger.Infow("Applying database migrations", "latest_version", latest)
+
+	return migration.Up(m, latest)
+}
+
+// CheckSchema reports whether the schema is at the version of the latest embedded migration
+func (pg *Postgres) CheckSchema(ctx context.Context) error {
+	latest, err := migration.Latest(migrations.FS, ".")
+	if err != nil {
+		return err
+	}
+
+	var version int64
+	var dirty bool
+	err = pg.db.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return migration.Matches(0, false, latest)
+	}
+	if err != nil {
+		return err
+	}
+
+	return migration.Matches(uint(version), dirty, latest)
+}
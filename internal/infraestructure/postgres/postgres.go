@@ -2,19 +2,27 @@ package postgres
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"go-clean-arch/internal/infraestructure/config"
-	"os"
+	"net"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/Masterminds/squirrel"
-	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 )
 
+// maxConnectBackoff caps the wait between two startup connection attempts
+const maxConnectBackoff = 30 * time.Second
+
 type Postgres struct {
-	db PG
+	db       PG
+	replicas atomic.Pointer[replicas]
+	config   *config.DB
 	zap.SugaredLogger
 }
 
@@ -22,62 +30,233 @@ type PG struct {
 	*pgxpool.Pool
 	QueryBuilder *squirrel.StatementBuilderType
 	url          string
+	credentials  *atomic.Pointer[credentials]
+}
+
+// credentials are the ones new connections authenticate with, they can change while the pool is open
+type credentials struct {
+	user     string
+	password string
+}
+
+// PoolStats is a snapshot of the connection pool, meant to be exported as metrics
+type PoolStats struct {
+	MaxConns                int32
+	TotalConns              int32
+	AcquiredConns           int32
+	IdleConns               int32
+	ConstructingConns       int32
+	AcquireCount            int64
+	EmptyAcquireCount       int64
+	CanceledAcquireCount    int64
+	AcquireDuration         time.Duration
+	NewConnsCount           int64
+	MaxLifetimeDestroyCount int64
+	MaxIdleDestroyCount     int64
 }
 
-func NewDatabase(ctx context.Context, configPG *config.DB, logger *zap.SugaredLogger) *Postgres {
-	pg, err := NewPostgres(ctx, configPG)
+func NewDatabase(ctx context.Context, configPG *config.DB, logger *zap.SugaredLogger) (*Postgres, error) {
+	pg, err := NewPostgres(ctx, configPG, logger)
 	if err != nil {
-		logger.Error("Error initializing database connection: %s", err)
-		os.Exit(1)
+		return nil, err
 	}
 
-	logger.Info("Successfully connected to the database", "db", configPG.Connection)
+	logger.Infow("Successfully connected to the database", "db", configPG.Connection)
+
+	replicas, err := newReplicas(ctx, configPG, logger)
+	if err != nil {
+		pg.Close()
+		return nil, err
+	}
 
-	return &Postgres{
+	postgres := &Postgres{
 		db:            *pg,
+		config:        configPG,
 		SugaredLogger: *logger,
 	}
+	postgres.replicas.Store(replicas)
+
+	return postgres, nil
+}
+
+// URL returns the connection string of the database described by config. It only
+// holds libpq settings so both pgx and the migration tool understand it.
+func URL(config *config.DB) string {
+	query := url.Values{}
+
+	query.Set("sslmode", config.SSLMode)
+
+	if config.SSLRootCert != "" {
+		query.Set("sslrootcert", config.SSLRootCert)
+	}
+	if config.SSLCert != "" {
+		query.Set("sslcert", config.SSLCert)
+	}
+	if config.SSLKey != "" {
+		query.Set("sslkey", config.SSLKey)
+	}
+	if config.ApplicationName != "" {
+		query.Set("application_name", config.ApplicationName)
+	}
+
+	u := url.URL{
+		Scheme:   config.Connection,
+		User:     url.UserPassword(config.User, config.Password),
+		Host:     net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+		Path:     "/" + config.Name,
+		RawQuery: query.Encode(),
+	}
 
+	return u.String()
 }
 
-func NewPostgres(ctx context.Context, config *config.DB) (*PG, error) {
-	url := fmt.Sprintf("%s://%s:%s@%s:%s/%s?sslmode=disable",
-		config.Connection,
-		config.User,
-		config.Password,
-		config.Host,
-		config.Port,
-		config.Name,
-	)
-
-	db, err := pgxpool.New(ctx, url)
+// poolConfig returns the settings of a pool connecting to connString as described by config,
+// pgx defaults are kept for the zero ones
+func poolConfig(connString string, config *config.DB) (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(connString)
 	if err != nil {
 		return nil, err
 	}
 
-	err = db.Ping(ctx)
+	if config.MaxConns > 0 {
+		poolConfig.MaxConns = config.MaxConns
+	}
+	if config.MinConns > 0 {
+		poolConfig.MinConns = config.MinConns
+	}
+	if config.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = config.MaxConnLifetime
+	}
+	if config.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = config.MaxConnIdleTime
+	}
+	if config.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = config.HealthCheckPeriod
+	}
+	if config.StatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(config.StatementTimeout.Milliseconds(), 10)
+	}
+
+	//Every query is traced, with its statement but without its parameters
+	poolConfig.ConnConfig.Tracer = newQueryTracer()
+
+	return poolConfig, nil
+}
+
+// NewPostgres connects to the database, retrying with an exponential backoff while it is unreachable
+func NewPostgres(ctx context.Context, config *config.DB, logger *zap.SugaredLogger) (*PG, error) {
+	poolConfig, err := poolConfig(URL(config), config)
 	if err != nil {
 		return nil, err
 	}
 
+	creds := &atomic.Pointer[credentials]{}
+	creds.Store(&credentials{config.User, config.Password})
+	poolConfig.BeforeConnect = func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+		current := creds.Load()
+		connConfig.User = current.user
+		connConfig.Password = current.password
+		return nil
+	}
+
+	retries := config.ConnectRetries
+	backoff := config.ConnectBackoff
+
+	db, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = db.Ping(ctx)
+		if err == nil {
+			break
+		}
+
+		if attempt >= retries {
+			db.Close()
+			return nil, fmt.Errorf("database unreachable after %d attempts: %w", attempt+1, err)
+		}
+
+		logger.Warn("Database unreachable, retrying in ", backoff, ": ", err)
+
+		select {
+		case <-ctx.Done():
+			db.Close()
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 
 	return &PG{
 		db,
 		&psql,
-		url,
+		poolConfig.ConnString(),
+		creds,
 	}, nil
 }
 
-func (db *PG) ErrorCode(err error) string {
-	var pgErr *pgconn.PgError
-	if errors.As(err, &pgErr) {
-		return pgErr.Code
-	} else {
-		return fmt.Sprintf("unexpected error: %s", err)
+func (db *PG) Close() {
+	db.Pool.Close()
+}
+
+// RotateCredentials makes new connections authenticate as user with password and recycles the
+// open ones, which are closed as soon as they are idle
+func (pg *Postgres) RotateCredentials(user, password string) {
+	pg.db.credentials.Store(&credentials{user, password})
+	pg.db.Reset()
+
+	pg.Info("Database credentials rotated, the connection pool was recycled")
+}
+
+// RotateReplicas replaces the replica pools by ones connecting to dsns, which hold their own
+// credentials. Reads move to the new pools at once, the old ones close when their queries are done.
+func (pg *Postgres) RotateReplicas(ctx context.Context, dsns []string) error {
+	configDB := *pg.config
+	configDB.Replicas = dsns
+
+	fresh, err := newReplicas(ctx, &configDB, &pg.SugaredLogger)
+	if err != nil {
+		return err
 	}
+
+	pg.replicas.Swap(fresh).close()
+
+	pg.Info("Database replicas changed, their connection pools were recycled")
+	return nil
 }
 
-func (db *PG) Close() {
-	db.Pool.Close()
+// Stats returns a snapshot of the connection pool
+func (pg *Postgres) Stats() PoolStats {
+	stat := pg.db.Stat()
+
+	return PoolStats{
+		MaxConns:                stat.MaxConns(),
+		TotalConns:              stat.TotalConns(),
+		AcquiredConns:           stat.AcquiredConns(),
+		IdleConns:               stat.IdleConns(),
+		ConstructingConns:       stat.ConstructingConns(),
+		AcquireCount:            stat.AcquireCount(),
+		EmptyAcquireCount:       stat.EmptyAcquireCount(),
+		CanceledAcquireCount:    stat.CanceledAcquireCount(),
+		AcquireDuration:         stat.AcquireDuration(),
+		NewConnsCount:           stat.NewConnsCount(),
+		MaxLifetimeDestroyCount: stat.MaxLifetimeDestroyCount(),
+		MaxIdleDestroyCount:     stat.MaxIdleDestroyCount(),
+	}
+}
+
+// Ping checks that the primary accepts connections, replicas are left to their own health checks
+func (pg *Postgres) Ping(ctx context.Context) error {
+	return pg.db.Ping(ctx)
+}
+
+// Close closes every connection of the primary and replica pools
+func (pg *Postgres) Close() {
+	pg.replicas.Load().close()
+	pg.db.Close()
 }
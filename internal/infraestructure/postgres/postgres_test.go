@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"go-clean-arch/internal/adapter/repository"
+	"go-clean-arch/internal/adapter/repository/repositorytest"
+	"go-clean-arch/internal/infraestructure/migration"
+	"go-clean-arch/migrations"
+	"os"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// testDSNEnv names the variable holding the DSN of a disposable database, the
+// tests are skipped when it is not set. Every table of that database is truncated.
+const testDSNEnv = "POSTGRES_TEST_DSN"
+
+func TestUserRepositoryContract(t *testing.T) {
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+
+	src, err := migration.Source(migrations.FS, ".")
+	if err != nil {
+		t.Fatalf("loading migrations: %v", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, dsn)
+	if err != nil {
+		t.Fatalf("loading migrations: %v", err)
+	}
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		t.Fatalf("running migrations: %v", err)
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connecting to %s: %v", testDSNEnv, err)
+	}
+	t.Cleanup(pool.Close)
+
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	pg := &Postgres{
+		db:            PG{pool, &psql, dsn, nil},
+		SugaredLogger: *zap.NewNop().Sugar(),
+	}
+
+	repositorytest.TestUserRepository(t, func(t *testing.T) repository.UserRepository {
+		_, err := pool.Exec(ctx, `TRUNCATE public."user", public.user_history, public.audit_log`)
+		if err != nil {
+			t.Fatalf("truncating tables: %v", err)
+		}
+
+		return pg
+	})
+}
@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"go-clean-arch/internal/adapter/repository"
+	"go-clean-arch/internal/infraestructure/config"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const replicaPingTimeout = 2 * time.Second
+
+type replica struct {
+	*pgxpool.Pool
+	host    string
+	healthy atomic.Bool
+}
+
+// replicas serves reads round-robin from the healthy replicas, pinging each of them periodically
+type replicas struct {
+	pools []*replica
+	next  atomic.Uint64
+	stop  chan struct{}
+	wg    sync.WaitGroup
+	zap.SugaredLogger
+}
+
+// newReplicas opens a pool per replica DSN in config. Unreachable replicas do not prevent
+// the application from starting, they are left out of the rotation until they answer a ping.
+func newReplicas(ctx context.Context, config *config.DB, logger *zap.SugaredLogger) (*replicas, error) {
+	r := &replicas{
+		stop:          make(chan struct{}),
+		SugaredLogger: *logger,
+	}
+
+	for _, dsn := range config.Replicas {
+		poolConfig, err := poolConfig(dsn, config)
+		if err != nil {
+			r.close()
+			return nil, err
+		}
+
+		pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+		if err != nil {
+			r.close()
+			return nil, err
+		}
+
+		r.pools = append(r.pools, &replica{Pool: pool, host: poolConfig.ConnConfig.Host})
+	}
+
+	if len(r.pools) == 0 {
+		return r, nil
+	}
+
+	r.check(ctx)
+
+	r.wg.Add(1)
+	go r.watch(config.ReplicaCheckPeriod)
+
+	return r, nil
+}
+
+// watch pings the replicas every period until close is called
+func (r *replicas) watch(period time.Duration) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.check(context.Background())
+		}
+	}
+}
+
+func (r *replicas) check(ctx context.Context) {
+	for _, replica := range r.pools {
+		pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+		err := replica.Ping(pingCtx)
+		cancel()
+
+		healthy := err == nil
+		if replica.healthy.Swap(healthy) != healthy {
+			if healthy {
+				r.Info("Database replica is back in rotation: ", replica.host)
+			} else {
+				r.Warn("Database replica left the rotation: ", replica.host, ": ", err)
+			}
+		}
+	}
+}
+
+// pick returns the next healthy replica, or nil when none is
+func (r *replicas) pick() *replica {
+	if r == nil || len(r.pools) == 0 {
+		return nil
+	}
+
+	n := uint64(len(r.pools))
+
+	start := r.next.Add(1)
+	for i := range n {
+		replica := r.pools[(start+i)%n]
+		if replica.healthy.Load() {
+			return replica
+		}
+	}
+
+	return nil
+}
+
+func (r *replicas) close() {
+	if r == nil {
+		return
+	}
+
+	select {
+	case <-r.stop:
+		return
+	default:
+		close(r.stop)
+	}
+
+	r.wg.Wait()
+
+	for _, replica := range r.pools {
+		replica.Close()
+	}
+}
+
+// read returns where a read outside of a write path should go: the transaction bound to ctx,
+// the primary once the request wrote something or asked for it, otherwise a healthy replica when there is one
+func (pg *Postgres) read(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+
+	if repository.ReadsPrimary(ctx) {
+		return pg.db.Pool
+	}
+
+	if replica := pg.replicas.Load().pick(); replica != nil {
+		return replica
+	}
+
+	return pg.db.Pool
+}
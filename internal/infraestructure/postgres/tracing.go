@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryTracer wraps every query of the pools in a span holding its statement and the rows it
+// returned or affected. Parameters are left out as they carry personal data.
+type queryTracer struct {
+	tracer trace.Tracer
+}
+
+var _ pgx.QueryTracer = &queryTracer{}
+
+func newQueryTracer() *queryTracer {
+	return &queryTracer{
+		otel.Tracer("go-clean-arch/internal/infraestructure/postgres"),
+	}
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	operation, _, _ := strings.Cut(strings.TrimSpace(data.SQL), " ")
+	operation = strings.ToUpper(operation)
+
+	ctx, _ = t.tracer.Start(ctx, "postgres "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", operation),
+			attribute.String("db.statement", data.SQL),
+			attribute.String("server.address", conn.Config().Host),
+		),
+	)
+
+	return ctx
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+		return
+	}
+
+	span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+}
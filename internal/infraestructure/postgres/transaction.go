@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"go-clean-arch/internal/adapter/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var _ repository.Transactor = &Postgres{}
+
+type txKey struct{}
+
+// querier is the set of methods shared by the pool and a transaction
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func (pg *Postgres) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	repository.MarkWritten(ctx)
+
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = fn(context.WithValue(ctx, txKey{}, tx))
+	if err != nil {
+		return err
+	}
+
+	//Serialization failures are only reported at commit time
+	return translateError(tx.Commit(ctx))
+}
+
+// conn returns the transaction bound to ctx, or the primary pool when there is none
+func (pg *Postgres) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+
+	return pg.db.Pool
+}
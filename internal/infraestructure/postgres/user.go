@@ -4,26 +4,35 @@ import (
 	"context"
 	"go-clean-arch/internal/adapter/repository"
 	"go-clean-arch/internal/core/domain"
+	"strings"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
-	"github.com/jackc/pgx/v5"
 )
 
 var _ repository.UserRepository = &Postgres{}
 
+var userColumns = []string{"id", "document", "name", "email", "age", "password", "created_at", "updated_at"}
+
+// returningUser is the suffix of the statements scanning the written user back
+var returningUser = "RETURNING " + strings.Join(userColumns, ", ")
+
 func (pg *Postgres) Save(ctx context.Context, user *domain.User) error {
+	repository.MarkWritten(ctx)
+
+	now := time.Now()
+
 	query := pg.db.QueryBuilder.Insert("public.user").
-		Columns("id", "document", "name", "email", "age", "password", "created_at", "updated_at").
-		Values(user.ID, user.Document, user.Name, user.Email, user.Age, user.Password, time.Now(), time.Now()).
-		Suffix("RETURNING *")
+		Columns(userColumns...).
+		Values(user.ID, user.Document, user.Name, user.Email, user.Age, user.Password, now, now).
+		Suffix(returningUser)
 
 	sql, args, err := query.ToSql()
 	if err != nil {
 		return err
 	}
 
-	err = pg.db.QueryRow(ctx, sql, args...).Scan(
+	err = pg.conn(ctx).QueryRow(ctx, sql, args...).Scan(
 		&user.ID,
 		&user.Document,
 		&user.Name,
@@ -34,10 +43,7 @@ func (pg *Postgres) Save(ctx context.Context, user *domain.User) error {
 		&user.UpdatedAt,
 	)
 	if err != nil {
-		if errCode := pg.db.ErrorCode(err); errCode == "23505" {
-			return domain.ErrConflictingData
-		}
-		return err
+		return translateError(err)
 	}
 
 	return nil
@@ -46,7 +52,7 @@ func (pg *Postgres) Save(ctx context.Context, user *domain.User) error {
 func (pg *Postgres) Get(ctx context.Context, id string) (*domain.User, error) {
 	var user domain.User
 
-	query := pg.db.QueryBuilder.Select("*").
+	query := pg.db.QueryBuilder.Select(userColumns...).
 		From("public.user").
 		Where(sq.Eq{"id": id}).
 		Limit(1)
@@ -56,7 +62,7 @@ func (pg *Postgres) Get(ctx context.Context, id string) (*domain.User, error) {
 		return nil, err
 	}
 
-	err = pg.db.QueryRow(ctx, sql, args...).Scan(
+	err = pg.read(ctx).QueryRow(ctx, sql, args...).Scan(
 		&user.ID,
 		&user.Document,
 		&user.Name,
@@ -67,20 +73,28 @@ func (pg *Postgres) Get(ctx context.Context, id string) (*domain.User, error) {
 		&user.UpdatedAt,
 	)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, domain.ErrDataNotFound
-		}
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	return &user, nil
 }
 
+// GetForUpdate locks the row of the user until the transaction of ctx ends, it always reads the primary
+func (pg *Postgres) GetForUpdate(ctx context.Context, id string) (*domain.User, error) {
+	query := pg.db.QueryBuilder.Select(userColumns...).
+		From("public.user").
+		Where(sq.Eq{"id": id}).
+		Limit(1).
+		Suffix("FOR UPDATE")
+
+	return pg.getUser(ctx, query)
+}
+
 func (pg *Postgres) List(ctx context.Context, skip, limit uint64) ([]domain.User, error) {
 	var user domain.User
 	var users []domain.User
 
-	query := pg.db.QueryBuilder.Select("*").
+	query := pg.db.QueryBuilder.Select(userColumns...).
 		From("public.user").
 		OrderBy("id").
 		Limit(limit).
@@ -91,9 +105,9 @@ func (pg *Postgres) List(ctx context.Context, skip, limit uint64) ([]domain.User
 		return nil, err
 	}
 
-	rows, err := pg.db.Query(ctx, sql, args...)
+	rows, err := pg.read(ctx).Query(ctx, sql, args...)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	defer rows.Close()
 
@@ -109,64 +123,83 @@ func (pg *Postgres) List(ctx context.Context, skip, limit uint64) ([]domain.User
 			&user.UpdatedAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 
 		users = append(users, user)
 	}
 
+	//An error ending the iteration early, such as a lost connection, is only reported here
+	err = rows.Err()
+	if err != nil {
+		return nil, translateError(err)
+	}
+
 	return users, nil
 }
 
 func (pg *Postgres) Update(ctx context.Context, user *domain.User) error {
-	query := pg.db.QueryBuilder.Update("public.user").
-		Set("document", sq.Expr("COALESCE(?, document)", user.Document)).
-		Set("name", sq.Expr("COALESCE(?, name)", user.Name)).
-		Set("email", sq.Expr("COALESCE(?, email)", user.Email)).
-		Set("age", sq.Expr("COALESCE(?, age)", user.Age)).
-		Set("password", sq.Expr("COALESCE(?, password)", user.Password)).
-		Set("updated_at", time.Now()).
-		Where(sq.Eq{"id": user.ID}).
-		Suffix("RETURNING *")
+	return pg.WithinTransaction(ctx, func(ctx context.Context) error {
+		now := time.Now()
 
-	sql, args, err := query.ToSql()
-	if err != nil {
-		return err
-	}
+		err := pg.saveHistory(ctx, user.ID, domain.UserOperationUpdate, now)
+		if err != nil {
+			return err
+		}
 
-	err = pg.db.QueryRow(ctx, sql, args...).Scan(
-		&user.ID,
-		&user.Document,
-		&user.Name,
-		&user.Email,
-		&user.Age,
-		&user.Password,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-	if err != nil {
-		if errCode := pg.db.ErrorCode(err); errCode == "23505" {
-			return domain.ErrConflictingData
+		query := pg.db.QueryBuilder.Update("public.user").
+			Set("document", sq.Expr("COALESCE(?, document)", user.Document)).
+			Set("name", sq.Expr("COALESCE(?, name)", user.Name)).
+			Set("email", sq.Expr("COALESCE(?, email)", user.Email)).
+			Set("age", sq.Expr("COALESCE(?, age)", user.Age)).
+			Set("password", sq.Expr("COALESCE(?, password)", user.Password)).
+			Set("updated_at", now).
+			Where(sq.Eq{"id": user.ID}).
+			Suffix(returningUser)
+
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return err
 		}
-		return err
-	}
 
-	return nil
+		err = pg.conn(ctx).QueryRow(ctx, sql, args...).Scan(
+			&user.ID,
+			&user.Document,
+			&user.Name,
+			&user.Email,
+			&user.Age,
+			&user.Password,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		)
+		if err != nil {
+			return translateError(err)
+		}
+
+		return nil
+	})
 }
 
 func (pg *Postgres) Delete(ctx context.Context, id string) error {
-	query := pg.db.QueryBuilder.Delete("public.user").
-		Where(sq.Eq{"id": id})
+	return pg.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := pg.saveHistory(ctx, id, domain.UserOperationDelete, time.Now())
+		if err != nil {
+			return err
+		}
 
-	sql, args, err := query.ToSql()
-	if err != nil {
-		return err
-	}
+		query := pg.db.QueryBuilder.Delete("public.user").
+			Where(sq.Eq{"id": id})
 
-	_, err = pg.db.Exec(ctx, sql, args...)
-	if err != nil {
-		return err
-	}
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return err
+		}
 
-	return nil
+		_, err = pg.conn(ctx).Exec(ctx, sql, args...)
+		if err != nil {
+			return translateError(err)
+		}
+
+		return nil
+	})
 }
@@ -0,0 +1,37 @@
+package redis
+
+import (
+	"context"
+	"go-clean-arch/internal/infraestructure/config"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// timeout bounds every call to Redis, a slow cache must not slow requests down
+const timeout = 200 * time.Millisecond
+
+func NewRedis(ctx context.Context, configRedis *config.Redis, logger *zap.SugaredLogger) *goredis.Client {
+	client := goredis.NewClient(&goredis.Options{
+		Addr:         configRedis.Addr,
+		Password:     configRedis.Password,
+		DialTimeout:  timeout,
+		ReadTimeout:  timeout,
+		WriteTimeout: timeout,
+		// Fail fast and let the caller fall back to the database instead of retrying
+		MaxRetries:    -1,
+		DialerRetries: 1,
+	})
+
+	// The cache is optional: when Redis is down requests fall back to the database
+	err := client.Ping(ctx).Err()
+	if err != nil {
+		logger.Warn("Redis is unreachable, the cache is bypassed until it is back: ", err)
+		return client
+	}
+
+	logger.Infow("Successfully connected to redis", "addr", configRedis.Addr)
+
+	return client
+}
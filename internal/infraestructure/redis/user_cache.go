@@ -0,0 +1,277 @@
+package redis
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"go-clean-arch/internal/adapter/repository"
+	"go-clean-arch/internal/core/domain"
+	"go-clean-arch/internal/core/logging"
+	"go-clean-arch/internal/infraestructure/config"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	_ repository.UserRepository = &UserCache{}
+	_ repository.Transactor     = &UserCache{}
+)
+
+const (
+	keyPrefix = "user:"
+	// leasePrefix keys the lease a miss takes before reading the store, its fill is only
+	// written while the lease is held, that is while no write invalidated the user meanwhile
+	leasePrefix = "user-lease:"
+	// leaseTTL bounds how long a miss may take to fill the cache
+	leaseTTL = 5 * time.Second
+)
+
+// notFound is cached for users that do not exist
+var notFound = []byte("null")
+
+// fill caches ARGV[2] at KEYS[1] for ARGV[3] milliseconds if the lease KEYS[2] still holds ARGV[1]
+var fill = goredis.NewScript(`
+if redis.call("GET", KEYS[2]) == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+	redis.call("DEL", KEYS[2])
+	return 1
+end
+return 0
+`)
+
+// cachedUser is what is cached of a user, its password hash never leaves the store
+type cachedUser struct {
+	ID        string    `json:"id"`
+	Document  string    `json:"document"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Age       int       `json:"age"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UserCache is a read-through cache for the Get of any UserRepository. Writes go
+// to the wrapped repository and invalidate the cached user. Any Redis failure is
+// logged and the wrapped repository is used instead.
+//
+// Users are cached without their password hash, Get returns them with an empty Password
+// whether they were cached or not. GetForUpdate is not cached and returns it.
+//
+// Misses read the primary, and their fill is dropped when a write invalidated the user
+// while they were reading it, so the cache never holds a state older than the last write.
+//
+// Transactions must be started with its WithinTransaction: their reads bypass the
+// cache and their invalidations wait until they are over.
+type UserCache struct {
+	repository.UserRepository
+	transactor  repository.Transactor
+	client      *goredis.Client
+	ttl         time.Duration
+	negativeTTL time.Duration
+	group       singleflight.Group
+	logger      *zap.SugaredLogger
+}
+
+func NewUserCache(next repository.UserRepository, transactor repository.Transactor, client *goredis.Client, configRedis *config.Redis, logger *zap.SugaredLogger) *UserCache {
+	return &UserCache{
+		UserRepository: next,
+		transactor:     transactor,
+		client:         client,
+		ttl:            configRedis.CacheTTL,
+		negativeTTL:    configRedis.NegativeCacheTTL,
+		logger:         logger,
+	}
+}
+
+type txKey struct{}
+
+// tx collects the users written by a transaction, invalidated once it is over
+type tx struct {
+	ids []string
+}
+
+// WithinTransaction runs fn in a transaction of the wrapped transactor. The users it writes
+// are invalidated after it commits or rolls back, so no read can cache what it replaced meanwhile.
+func (c *UserCache) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*tx); ok {
+		return c.transactor.WithinTransaction(ctx, fn)
+	}
+
+	t := &tx{}
+	err := c.transactor.WithinTransaction(context.WithValue(ctx, txKey{}, t), fn)
+	for _, id := range t.ids {
+		c.del(ctx, id)
+	}
+
+	return err
+}
+
+func (c *UserCache) Get(ctx context.Context, id string) (*domain.User, error) {
+	//A transaction reads its own uncommitted writes, they are neither served nor filled from the cache
+	if _, ok := ctx.Value(txKey{}).(*tx); ok {
+		return c.UserRepository.Get(ctx, id)
+	}
+
+	key := keyPrefix + id
+
+	cached, err := c.client.Get(ctx, key).Bytes()
+	switch {
+	case err == nil:
+		if bytes.Equal(cached, notFound) {
+			return nil, domain.ErrDataNotFound
+		}
+
+		var user cachedUser
+		if err := json.Unmarshal(cached, &user); err == nil {
+			return user.user(), nil
+		}
+		logging.FromContext(ctx, c.logger).Warn("failed to decode cached user: ", err)
+	case !errors.Is(err, goredis.Nil):
+		logging.FromContext(ctx, c.logger).Warn("failed to read user from cache: ", err)
+	}
+
+	//A request that wrote must read its own writes, not a read another request started before them
+	if repository.HasWritten(ctx) {
+		loaded, err := c.load(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return loaded.user(), nil
+	}
+
+	// Concurrent misses for the same user share a single database read. It must
+	// not be cancelled along with whichever request happened to start it.
+	shared, err, _ := c.group.Do(id, func() (any, error) {
+		return c.load(context.WithoutCancel(ctx), id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shared.(*cachedUser).user(), nil
+}
+
+// load reads the user from the primary and caches it, or its absence, unless it was
+// invalidated meanwhile. A replica could return a state older than the last invalidation.
+func (c *UserCache) load(ctx context.Context, id string) (*cachedUser, error) {
+	token := c.lease(ctx, id)
+
+	user, err := c.UserRepository.Get(repository.WithPrimary(ctx), id)
+	switch {
+	case err == nil:
+		cached := newCachedUser(user)
+		c.set(ctx, id, token, cached, c.ttl)
+		return cached, nil
+	case errors.Is(err, domain.ErrDataNotFound):
+		c.set(ctx, id, token, nil, c.negativeTTL)
+	}
+
+	return nil, err
+}
+
+// lease takes the lease of the user before its store read, it returns an empty token when
+// Redis is unreachable, in which case nothing is cached
+func (c *UserCache) lease(ctx context.Context, id string) string {
+	token := rand.Text()
+
+	err := c.client.Set(ctx, leasePrefix+id, token, leaseTTL).Err()
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Warn("failed to lease user cache entry: ", err)
+		return ""
+	}
+
+	return token
+}
+
+func (c *UserCache) Save(ctx context.Context, user *domain.User) error {
+	err := c.UserRepository.Save(ctx, user)
+	c.invalidate(ctx, user.ID)
+
+	return err
+}
+
+func (c *UserCache) Update(ctx context.Context, user *domain.User) error {
+	err := c.UserRepository.Update(ctx, user)
+	c.invalidate(ctx, user.ID)
+
+	return err
+}
+
+func (c *UserCache) Delete(ctx context.Context, id string) error {
+	err := c.UserRepository.Delete(ctx, id)
+	c.invalidate(ctx, id)
+
+	return err
+}
+
+// set caches user, or the absence of the user when it is nil, if the lease token is still held
+func (c *UserCache) set(ctx context.Context, id, token string, user *cachedUser, ttl time.Duration) {
+	if token == "" {
+		return
+	}
+
+	value := notFound
+	if user != nil {
+		var err error
+		value, err = json.Marshal(user)
+		if err != nil {
+			logging.FromContext(ctx, c.logger).Warn("failed to encode user for cache: ", err)
+			return
+		}
+	}
+
+	keys := []string{keyPrefix + id, leasePrefix + id}
+	err := fill.Run(ctx, c.client, keys, token, value, ttl.Milliseconds()).Err()
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Warn("failed to write user to cache: ", err)
+	}
+}
+
+// invalidate drops the cached user once the write is visible to other reads: right away
+// outside of a transaction, after the transaction of ctx is over otherwise
+func (c *UserCache) invalidate(ctx context.Context, id string) {
+	if t, ok := ctx.Value(txKey{}).(*tx); ok {
+		t.ids = append(t.ids, id)
+		return
+	}
+
+	c.del(ctx, id)
+}
+
+// del drops the cached user and the lease of any miss reading it, so its fill is dropped too
+func (c *UserCache) del(ctx context.Context, id string) {
+	err := c.client.Del(context.WithoutCancel(ctx), keyPrefix+id, leasePrefix+id).Err()
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Warn("failed to invalidate cached user: ", err)
+	}
+}
+
+func newCachedUser(user *domain.User) *cachedUser {
+	return &cachedUser{
+		ID:        user.ID,
+		Document:  user.Document,
+		Name:      user.Name,
+		Email:     user.Email,
+		Age:       user.Age,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
+
+// domain returns a copy of the cached user, without its password
+func (u *cachedUser) user() *domain.User {
+	return &domain.User{
+		ID:        u.ID,
+		Document:  u.Document,
+		Name:      u.Name,
+		Email:     u.Email,
+		Age:       u.Age,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
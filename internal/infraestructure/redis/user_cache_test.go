@@ -0,0 +1,264 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"go-clean-arch/internal/adapter/repository"
+	"go-clean-arch/internal/core/domain"
+	"go-clean-arch/internal/infraestructure/config"
+	"go-clean-arch/internal/infraestructure/memory"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"go.uber.org/zap"
+)
+
+const negativeTTL = time.Second
+
+// store counts the reads reaching the wrapped repository, and the ones not asking for the
+// primary, afterGet runs once each of them is done
+type store struct {
+	repository.UserRepository
+	reads        atomic.Int32
+	replicaReads atomic.Int32
+	afterGet     func()
+}
+
+func (s *store) Get(ctx context.Context, id string) (*domain.User, error) {
+	s.reads.Add(1)
+	if !repository.ReadsPrimary(ctx) {
+		s.replicaReads.Add(1)
+	}
+	user, err := s.UserRepository.Get(ctx, id)
+	if s.afterGet != nil {
+		s.afterGet()
+	}
+
+	return user, err
+}
+
+// newTestCache returns a cache in front of an in-memory database holding the user with id "1"
+func newTestCache(t *testing.T) (*UserCache, *store, *miniredis.Miniredis) {
+	ctx := context.Background()
+	logger := zap.NewNop().Sugar()
+
+	server := miniredis.RunT(t)
+	configRedis := &config.Redis{Addr: server.Addr(), CacheTTL: time.Minute, NegativeCacheTTL: negativeTTL}
+	client := NewRedis(ctx, configRedis, logger)
+	t.Cleanup(func() { client.Close() })
+
+	database := memory.NewDatabase(logger)
+	err := database.Save(ctx, newTestUser("John Doe"))
+	if err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+
+	s := &store{UserRepository: database}
+
+	return NewUserCache(s, database, client, configRedis, logger), s, server
+}
+
+func newTestUser(name string) *domain.User {
+	return &domain.User{
+		ID:       "1",
+		Document: "12345678911",
+		Name:     name,
+		Email:    "john@example.com",
+		Age:      30,
+		Password: "$2a$10$hash",
+	}
+}
+
+// get reads the user through the cache and fails the test unless it is named name
+func get(t *testing.T, cache *UserCache, name string) {
+	t.Helper()
+
+	user, err := cache.Get(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("Get: unexpected error: %v", err)
+	}
+	if user.Name != name {
+		t.Fatalf("Get: got name %q, want %q", user.Name, name)
+	}
+	if user.Password != "" {
+		t.Fatalf("Get: got password %q, want none", user.Password)
+	}
+}
+
+func wantReads(t *testing.T, s *store, want int32) {
+	t.Helper()
+
+	if got := s.reads.Load(); got != want {
+		t.Fatalf("got %d store reads, want %d", got, want)
+	}
+	if got := s.replicaReads.Load(); got != 0 {
+		t.Fatalf("got %d store reads allowed on a replica, want none", got)
+	}
+}
+
+func TestUserCacheHitAndMiss(t *testing.T) {
+	cache, s, server := newTestCache(t)
+
+	get(t, cache, "John Doe")
+	wantReads(t, s, 1)
+
+	cached, err := server.Get(keyPrefix + "1")
+	if err != nil {
+		t.Fatalf("user not cached: %v", err)
+	}
+	if strings.Contains(cached, "$2a$10$hash") {
+		t.Fatalf("cached user holds the password hash: %s", cached)
+	}
+
+	get(t, cache, "John Doe")
+	wantReads(t, s, 1)
+
+	server.FastForward(time.Minute)
+	get(t, cache, "John Doe")
+	wantReads(t, s, 2)
+}
+
+func TestUserCacheNegative(t *testing.T) {
+	cache, s, server := newTestCache(t)
+	ctx := context.Background()
+
+	for range 2 {
+		_, err := cache.Get(ctx, "2")
+		if !errors.Is(err, domain.ErrDataNotFound) {
+			t.Fatalf("Get: got error %v, want %v", err, domain.ErrDataNotFound)
+		}
+	}
+	wantReads(t, s, 1)
+
+	server.FastForward(negativeTTL)
+	_, err := cache.Get(ctx, "2")
+	if !errors.Is(err, domain.ErrDataNotFound) {
+		t.Fatalf("Get: got error %v, want %v", err, domain.ErrDataNotFound)
+	}
+	wantReads(t, s, 2)
+}
+
+func TestUserCacheInvalidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(ctx context.Context, cache *UserCache) error
+		// want is the name read after the write, empty when the user is deleted
+		want string
+	}{
+		{
+			name:  "update",
+			write: func(ctx context.Context, cache *UserCache) error { return cache.Update(ctx, newTestUser("Jane Doe")) },
+			want:  "Jane Doe",
+		},
+		{
+			name:  "delete",
+			write: func(ctx context.Context, cache *UserCache) error { return cache.Delete(ctx, "1") },
+		},
+		{
+			name: "update in a transaction",
+			write: func(ctx context.Context, cache *UserCache) error {
+				return cache.WithinTransaction(ctx, func(ctx context.Context) error {
+					return cache.Update(ctx, newTestUser("Jane Doe"))
+				})
+			},
+			want: "Jane Doe",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, _, server := newTestCache(t)
+			ctx := context.Background()
+
+			get(t, cache, "John Doe")
+
+			err := tt.write(ctx, cache)
+			if err != nil {
+				t.Fatalf("write: unexpected error: %v", err)
+			}
+			if server.Exists(keyPrefix + "1") {
+				t.Fatal("user still cached after the write")
+			}
+
+			if tt.want == "" {
+				_, err = cache.Get(ctx, "1")
+				if !errors.Is(err, domain.ErrDataNotFound) {
+					t.Fatalf("Get: got error %v, want %v", err, domain.ErrDataNotFound)
+				}
+				return
+			}
+			get(t, cache, tt.want)
+		})
+	}
+}
+
+func TestUserCacheDropsFillRacingWrite(t *testing.T) {
+	cache, s, server := newTestCache(t)
+	ctx := context.Background()
+
+	//The user is updated after the miss read it and before it fills the cache
+	var once sync.Once
+	s.afterGet = func() {
+		once.Do(func() {
+			err := cache.Update(ctx, newTestUser("Jane Doe"))
+			if err != nil {
+				t.Errorf("Update: unexpected error: %v", err)
+			}
+		})
+	}
+
+	get(t, cache, "John Doe")
+	if server.Exists(keyPrefix + "1") {
+		t.Fatal("the state read before the update was cached")
+	}
+
+	get(t, cache, "Jane Doe")
+	get(t, cache, "Jane Doe")
+	wantReads(t, s, 2)
+}
+
+func TestUserCacheSingleflight(t *testing.T) {
+	cache, s, _ := newTestCache(t)
+
+	//The first read is held until every request missed
+	release := make(chan struct{})
+	s.afterGet = func() { <-release }
+
+	const requests = 10
+	var wg sync.WaitGroup
+	for range requests {
+		wg.Go(func() {
+			_, err := cache.Get(context.Background(), "1")
+			if err != nil {
+				t.Errorf("Get: unexpected error: %v", err)
+			}
+		})
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	wantReads(t, s, 1)
+}
+
+func TestUserCacheRedisDown(t *testing.T) {
+	cache, s, server := newTestCache(t)
+	ctx := context.Background()
+
+	server.Close()
+
+	get(t, cache, "John Doe")
+	get(t, cache, "John Doe")
+	wantReads(t, s, 2)
+
+	err := cache.Update(ctx, newTestUser("Jane Doe"))
+	if err != nil {
+		t.Fatalf("Update: unexpected error: %v", err)
+	}
+	get(t, cache, "Jane Doe")
+}
@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"go-clean-arch/internal/adapter/repository"
+	"go-clean-arch/internal/core/domain"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+var _ repository.AuditRepository = &SQLite{}
+
+func (s *SQLite) SaveAudit(ctx context.Context, log *domain.AuditLog) error {
+	changes, err := json.Marshal(log.Changes)
+	if err != nil {
+		return err
+	}
+
+	query := s.QueryBuilder.Insert("audit_log").
+		Columns("id", "actor", "actor_verified", "action", "target_type", "target_id", "changes", "request_id", "client_ip", "created_at").
+		Values(log.ID, log.Actor, log.ActorVerified, string(log.Action), log.TargetType, log.TargetID, string(changes), log.RequestID, log.ClientIP, utc(log.CreatedAt))
+
+	stmt, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn(ctx).ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (s *SQLite) ListAudit(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, error) {
+	var logs []domain.AuditLog
+
+	query := s.QueryBuilder.Select("id", "actor", "actor_verified", "action", "target_type", "target_id", "changes", "request_id", "client_ip", "created_at").
+		From("audit_log").
+		OrderBy("created_at DESC", "id").
+		Limit(filter.Limit).
+		Offset(filter.Skip * filter.Limit)
+
+	if filter.Actor != "" {
+		query = query.Where(sq.Eq{"actor": filter.Actor})
+	}
+	if filter.Action != "" {
+		query = query.Where(sq.Eq{"action": string(filter.Action)})
+	}
+	if filter.TargetID != "" {
+		query = query.Where(sq.Eq{"target_id": filter.TargetID})
+	}
+	if !filter.From.IsZero() {
+		query = query.Where(sq.GtOrEq{"created_at": utc(filter.From)})
+	}
+	if !filter.To.IsZero() {
+		query = query.Where(sq.Lt{"created_at": utc(filter.To)})
+	}
+
+	stmt, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var log domain.AuditLog
+		var changes string
+
+		err := rows.Scan(
+			&log.ID,
+			&log.Actor,
+			&log.ActorVerified,
+			&log.Action,
+			&log.TargetType,
+			&log.TargetID,
+			&changes,
+			&log.RequestID,
+			&log.ClientIP,
+			&log.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal([]byte(changes), &log.Changes)
+		if err != nil {
+			return nil, err
+		}
+
+		logs = append(logs, log)
+	}
+
+	return logs, rows.Err()
+}
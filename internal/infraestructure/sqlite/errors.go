@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"errors"
+	"go-clean-arch/internal/core/domain"
+	"strings"
+
+	sqlitedriver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// constraintFields maps the named CHECK constraints of the schema to the field they guard, the
+// other constraints are reported by SQLite with the table and column they failed on
+var constraintFields = map[string]string{
+	"user_age_check": "age",
+}
+
+// translateError maps the constraint violations reported by SQLite to domain errors, keeping the
+// original one as cause. Errors it does not know about are returned untouched.
+func translateError(err error) error {
+	var sqliteErr *sqlitedriver.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return constraintError(domain.ErrConflictingData, "already in use", sqliteErr)
+	case sqlite3.SQLITE_CONSTRAINT_CHECK, sqlite3.SQLITE_CONSTRAINT_NOTNULL:
+		return constraintError(domain.ErrInvalidData, "is invalid", sqliteErr)
+	}
+
+	return err
+}
+
+// constraintError refines sentinel with the field sqliteErr is about, when it is known
+func constraintError(sentinel *domain.Error, problem string, sqliteErr *sqlitedriver.Error) error {
+	field := constraintField(sqliteErr.Error())
+	if field == "" {
+		return sentinel.Wrap(sqliteErr)
+	}
+
+	return sentinel.
+		WithMessage(field + " " + problem).
+		WithDetails(domain.ErrorDetail{Field: field, Message: problem}).
+		Wrap(sqliteErr)
+}
+
+// constraintField returns the field of a message such as "UNIQUE constraint failed: user.email (2067)"
+// or "CHECK constraint failed: user_age_check (275)", the first one when several columns are listed
+func constraintField(message string) string {
+	const prefix = "constraint failed: "
+
+	i := strings.LastIndex(message, prefix)
+	if i < 0 {
+		return ""
+	}
+
+	subject, _, _ := strings.Cut(message[i+len(prefix):], " (")
+	subject, _, _ = strings.Cut(subject, ", ")
+
+	if field, ok := constraintFields[subject]; ok {
+		return field
+	}
+	if _, column, ok := strings.Cut(subject, "."); ok {
+		return column
+	}
+
+	return ""
+}
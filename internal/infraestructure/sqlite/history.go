@@ -0,0 +1,117 @@
+package sqlite
+
+import (
+	"context"
+	"go-clean-arch/internal/core/domain"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// saveHistory copies the current row of the user into user_history, closing its
+// validity at validTo. It must run in the same transaction as the change itself.
+func (s *SQLite) saveHistory(ctx context.Context, id, operation string, validTo time.Time) error {
+	current := s.QueryBuilder.Select(userColumns...).
+		Column("?", operation).
+		Column("COALESCE(updated_at, created_at)").
+		Column("?", utc(validTo)).
+		From("user").
+		Where(sq.Eq{"id": id})
+
+	query := s.QueryBuilder.Insert("user_history").
+		Columns(append(userColumns, "operation", "valid_from", "valid_to")...).
+		Select(current)
+
+	stmt, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	result, err := s.conn(ctx).ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return translateError(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return domain.ErrDataNotFound
+	}
+
+	return nil
+}
+
+func (s *SQLite) History(ctx context.Context, id string, skip, limit uint64) ([]domain.UserVersion, error) {
+	var version domain.UserVersion
+	var versions []domain.UserVersion
+
+	query := s.QueryBuilder.Select(userColumns...).
+		Columns("operation", "valid_from", "valid_to").
+		From("user_history").
+		Where(sq.Eq{"id": id}).
+		OrderBy("valid_to DESC", "history_id DESC").
+		Limit(limit).
+		Offset(skip * limit)
+
+	stmt, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err := rows.Scan(
+			&version.ID,
+			&version.Document,
+			&version.Name,
+			&version.Email,
+			&version.Age,
+			&version.Password,
+			&version.CreatedAt,
+			&version.UpdatedAt,
+			&version.Operation,
+			&version.ValidFrom,
+			&version.ValidTo,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, version)
+	}
+
+	return versions, rows.Err()
+}
+
+// GetAsOf returns the user as it was at asOf: the history row valid at that
+// time or, when none is, the current row if it was already in place.
+func (s *SQLite) GetAsOf(ctx context.Context, id string, asOf time.Time) (*domain.User, error) {
+	past := s.QueryBuilder.Select(userColumns...).
+		From("user_history").
+		Where(sq.Eq{"id": id}).
+		Where(sq.LtOrEq{"valid_from": utc(asOf)}).
+		Where(sq.Gt{"valid_to": utc(asOf)}).
+		OrderBy("valid_to").
+		Limit(1)
+
+	user, err := s.getUser(ctx, past)
+	if err != domain.ErrDataNotFound {
+		return user, err
+	}
+
+	current := s.QueryBuilder.Select(userColumns...).
+		From("user").
+		Where(sq.Eq{"id": id}).
+		Where(sq.LtOrEq{"COALESCE(updated_at, created_at)": utc(asOf)}).
+		Limit(1)
+
+	return s.getUser(ctx, current)
+}
@@ -0,0 +1,13 @@
+CREATE TABLE user (
+    id TEXT NOT NULL,
+    document TEXT NOT NULL,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    age INTEGER NOT NULL,
+    password TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    CONSTRAINT user_pk PRIMARY KEY (id),
+    CONSTRAINT user_unique_document UNIQUE (document),
+    CONSTRAINT user_unique_email UNIQUE (email)
+);
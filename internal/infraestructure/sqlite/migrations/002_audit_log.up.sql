@@ -0,0 +1,18 @@
+CREATE TABLE audit_log (
+    id TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    changes TEXT NOT NULL DEFAULT '{}',
+    request_id TEXT NOT NULL DEFAULT '',
+    client_ip TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    CONSTRAINT audit_log_pk PRIMARY KEY (id)
+);
+
+CREATE INDEX audit_log_target_idx ON audit_log (target_type, target_id, created_at);
+
+CREATE INDEX audit_log_actor_idx ON audit_log (actor, created_at);
+
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
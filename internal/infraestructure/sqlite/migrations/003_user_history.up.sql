@@ -0,0 +1,16 @@
+CREATE TABLE user_history (
+    history_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    id TEXT NOT NULL,
+    document TEXT NOT NULL,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    age INTEGER NOT NULL,
+    password TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    operation TEXT NOT NULL,
+    valid_from TIMESTAMP NOT NULL,
+    valid_to TIMESTAMP NOT NULL
+);
+
+CREATE INDEX user_history_id_validity_idx ON user_history (id, valid_from, valid_to);
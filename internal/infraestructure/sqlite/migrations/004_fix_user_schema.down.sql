@@ -0,0 +1,33 @@
+DROP INDEX IF EXISTS audit_log_created_at_idx;
+DROP INDEX IF EXISTS audit_log_action_idx;
+DROP INDEX IF EXISTS audit_log_actor_idx;
+DROP INDEX IF EXISTS audit_log_target_id_idx;
+
+CREATE INDEX audit_log_target_idx ON audit_log (target_type, target_id, created_at);
+
+CREATE INDEX audit_log_actor_idx ON audit_log (actor, created_at);
+
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+
+DROP INDEX IF EXISTS user_history_id_valid_to_idx;
+
+CREATE TABLE user_old (
+    id TEXT NOT NULL,
+    document TEXT NOT NULL,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    age INTEGER NOT NULL,
+    password TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    CONSTRAINT user_pk PRIMARY KEY (id),
+    CONSTRAINT user_unique_document UNIQUE (document),
+    CONSTRAINT user_unique_email UNIQUE (email)
+);
+
+INSERT INTO user_old (id, document, name, email, age, password, created_at, updated_at)
+    SELECT id, document, name, email, age, password, created_at, updated_at FROM user;
+
+DROP TABLE user;
+
+ALTER TABLE user_old RENAME TO user;
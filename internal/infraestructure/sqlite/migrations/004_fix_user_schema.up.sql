@@ -0,0 +1,48 @@
+-- Users whose emails only differ in case must be merged by hand first, the check fails while there are any
+CREATE TEMP TABLE email_case_duplicates (
+    duplicates INTEGER NOT NULL,
+    CONSTRAINT merge_users_whose_emails_only_differ_in_case CHECK (duplicates = 0)
+);
+
+INSERT INTO email_case_duplicates (duplicates)
+    SELECT count(*) FROM (SELECT 1 FROM user GROUP BY email COLLATE NOCASE HAVING count(*) > 1);
+
+DROP TABLE email_case_duplicates;
+
+-- SQLite cannot add constraints to an existing table, it is rebuilt instead
+CREATE TABLE user_new (
+    id TEXT NOT NULL,
+    document TEXT NOT NULL,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL COLLATE NOCASE,
+    age INTEGER NOT NULL,
+    password TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    CONSTRAINT user_pk PRIMARY KEY (id),
+    CONSTRAINT user_unique_document UNIQUE (document),
+    CONSTRAINT user_unique_email UNIQUE (email),
+    CONSTRAINT user_age_check CHECK (age BETWEEN 0 AND 150)
+);
+
+INSERT INTO user_new (id, document, name, email, age, password, created_at, updated_at)
+    SELECT id, document, name, email, age, password, created_at, updated_at FROM user;
+
+DROP TABLE user;
+
+ALTER TABLE user_new RENAME TO user;
+
+CREATE INDEX user_history_id_valid_to_idx ON user_history (id, valid_to DESC, history_id DESC);
+
+-- Serve the audit listing, filtered by any of actor, action and target ID and sorted by created_at DESC, id
+DROP INDEX audit_log_target_idx;
+DROP INDEX audit_log_actor_idx;
+DROP INDEX audit_log_created_at_idx;
+
+CREATE INDEX audit_log_target_id_idx ON audit_log (target_id, created_at DESC, id);
+
+CREATE INDEX audit_log_actor_idx ON audit_log (actor, created_at DESC, id);
+
+CREATE INDEX audit_log_action_idx ON audit_log (action, created_at DESC, id);
+
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at DESC, id);
@@ -0,0 +1,21 @@
+CREATE TABLE user_old (
+    id TEXT NOT NULL,
+    document TEXT NOT NULL,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL COLLATE NOCASE,
+    age INTEGER NOT NULL,
+    password TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    CONSTRAINT user_pk PRIMARY KEY (id),
+    CONSTRAINT user_unique_document UNIQUE (document),
+    CONSTRAINT user_unique_email UNIQUE (email),
+    CONSTRAINT user_age_check CHECK (age BETWEEN 0 AND 150)
+);
+
+INSERT INTO user_old (id, document, name, email, age, password, created_at, updated_at)
+    SELECT id, document, name, email, age, password, created_at, updated_at FROM user;
+
+DROP TABLE user;
+
+ALTER TABLE user_old RENAME TO user;
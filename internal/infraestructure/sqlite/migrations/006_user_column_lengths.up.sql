@@ -0,0 +1,28 @@
+-- TEXT columns have no size, the ones of the VARCHAR columns of Postgres are checked instead.
+-- SQLite cannot add constraints to an existing table, it is rebuilt instead
+CREATE TABLE user_new (
+    id TEXT NOT NULL,
+    document TEXT NOT NULL,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL COLLATE NOCASE,
+    age INTEGER NOT NULL,
+    password TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    CONSTRAINT user_pk PRIMARY KEY (id),
+    CONSTRAINT user_unique_document UNIQUE (document),
+    CONSTRAINT user_unique_email UNIQUE (email),
+    CONSTRAINT user_age_check CHECK (age BETWEEN 0 AND 150),
+    CONSTRAINT user_id_length CHECK (length(id) <= 64),
+    CONSTRAINT user_document_length CHECK (length(document) <= 20),
+    CONSTRAINT user_name_length CHECK (length(name) <= 255),
+    CONSTRAINT user_email_length CHECK (length(email) <= 254),
+    CONSTRAINT user_password_length CHECK (length(password) <= 72)
+);
+
+INSERT INTO user_new (id, document, name, email, age, password, created_at, updated_at)
+    SELECT id, document, name, email, age, password, created_at, updated_at FROM user;
+
+DROP TABLE user;
+
+ALTER TABLE user_new RENAME TO user;
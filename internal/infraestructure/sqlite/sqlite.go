@@ -0,0 +1,152 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"go-clean-arch/internal/adapter/repository"
+	"go-clean-arch/internal/infraestructure/config"
+	"go-clean-arch/internal/infraestructure/migration"
+	"net/url"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/golang-migrate/migrate/v4"
+	migratesqlite "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"go.uber.org/zap"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+var _ repository.Transactor = &SQLite{}
+
+type SQLite struct {
+	db           *sql.DB
+	QueryBuilder sq.StatementBuilderType
+	zap.SugaredLogger
+}
+
+func NewDatabase(ctx context.Context, configDB *config.DB, logger *zap.SugaredLogger) (*SQLite, error) {
+	db, err := NewSQLite(ctx, configDB)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infow("Successfully connected to the database", "db", configDB.Connection, "file", configDB.Name)
+
+	return &SQLite{
+		db:            db,
+		QueryBuilder:  sq.StatementBuilder.PlaceholderFormat(sq.Question),
+		SugaredLogger: *logger,
+	}, nil
+}
+
+// NewSQLite opens the database file named by config.Name, creating it if needed,
+// and brings its schema up to date with the embedded migrations
+func NewSQLite(ctx context.Context, config *config.DB) (*sql.DB, error) {
+	// Times are stored as UTC text, always written through utc, so they compare
+	// and sort like the postgres TIMESTAMP columns they mirror. The file name is escaped
+	// as a URI path, a ? or # in it would otherwise start the parameters or a fragment.
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite",
+		(&url.URL{Path: config.Name}).EscapedPath(),
+	)
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer, sharing one connection avoids SQLITE_BUSY errors
+	db.SetMaxOpenConns(1)
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	err = migrateUp(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func migrateUp(db *sql.DB) error {
+	latest, err := migration.Latest(migrations, "migrations")
+	if err != nil {
+		return err
+	}
+
+	source, err := migration.Source(migrations, "migrations")
+	if err != nil {
+		return err
+	}
+
+	driver, err := migratesqlite.WithInstance(db, &migratesqlite.Config{})
+	if err != nil {
+		return err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, "sqlite", driver)
+	if err != nil {
+		return err
+	}
+
+	return migration.Up(m, latest)
+}
+
+type txKey struct{}
+
+// querier is the set of methods shared by the database and a transaction
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (s *SQLite) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(context.WithValue(ctx, txKey{}, tx))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// conn returns the transaction bound to ctx, or the database when there is none
+func (s *SQLite) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return s.db
+}
+
+// utc returns t in the single location and precision times are stored with
+func utc(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// Ping checks that the database file can still be used
+func (s *SQLite) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
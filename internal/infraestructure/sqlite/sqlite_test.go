@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"go-clean-arch/internal/adapter/repository"
+	"go-clean-arch/internal/adapter/repository/repositorytest"
+	"go-clean-arch/internal/core/domain"
+	"go-clean-arch/internal/infraestructure/config"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func newTestDatabase(t *testing.T) *SQLite {
+	configDB := &config.DB{
+		Connection: "sqlite",
+		Name:       filepath.Join(t.TempDir(), "test.db"),
+	}
+
+	db, err := NewDatabase(context.Background(), configDB, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatalf("NewDatabase: unexpected error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestUserRepositoryContract(t *testing.T) {
+	repositorytest.TestUserRepository(t, func(t *testing.T) repository.UserRepository {
+		return newTestDatabase(t)
+	})
+}
+
+func TestConstraintErrors(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+
+	err := db.Save(ctx, &domain.User{ID: "1", Document: "12345678911", Name: "John", Email: "john@example.com", Age: 30, Password: "hash"})
+	if err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		user  *domain.User
+		want  *domain.Error
+		field string
+	}{
+		{"id", &domain.User{ID: "1", Document: "2", Email: "2@example.com", Age: 30}, domain.ErrConflictingData, "id"},
+		{"document", &domain.User{ID: "2", Document: "12345678911", Email: "2@example.com", Age: 30}, domain.ErrConflictingData, "document"},
+		{"email", &domain.User{ID: "2", Document: "2", Email: "JOHN@example.com", Age: 30}, domain.ErrConflictingData, "email"},
+		{"age", &domain.User{ID: "2", Document: "2", Email: "2@example.com", Age: 200}, domain.ErrInvalidData, "age"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.Save(ctx, tt.user)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got error %v, want %v", err, tt.want)
+			}
+
+			domainErr := domain.AsError(err)
+			if len(domainErr.Details) != 1 || domainErr.Details[0].Field != tt.field {
+				t.Fatalf("got details %+v, want one for %s", domainErr.Details, tt.field)
+			}
+		})
+	}
+}
+
+func TestNewSQLiteEscapesName(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "data?mode=ro#1 100%.db")
+
+	db, err := NewSQLite(context.Background(), &config.DB{Connection: "sqlite", Name: name})
+	if err != nil {
+		t.Fatalf("NewSQLite: unexpected error: %v", err)
+	}
+	db.Close()
+
+	_, err = os.Stat(name)
+	if err != nil {
+		t.Fatalf("database not created at %q: %v", name, err)
+	}
+}
@@ -0,0 +1,201 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"go-clean-arch/internal/adapter/repository"
+	"go-clean-arch/internal/core/domain"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+var _ repository.UserRepository = &SQLite{}
+
+var userColumns = []string{"id", "document", "name", "email", "age", "password", "created_at", "updated_at"}
+
+func (s *SQLite) Save(ctx context.Context, user *domain.User) error {
+	now := utc(time.Now())
+
+	query := s.QueryBuilder.Insert("user").
+		Columns(userColumns...).
+		Values(user.ID, user.Document, user.Name, user.Email, user.Age, user.Password, now, now).
+		Suffix("RETURNING " + columns(userColumns))
+
+	stmt, args, err := query.ToSql()
+	if err != nil {
+		return err
+	}
+
+	err = s.conn(ctx).QueryRowContext(ctx, stmt, args...).Scan(
+		&user.ID,
+		&user.Document,
+		&user.Name,
+		&user.Email,
+		&user.Age,
+		&user.Password,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (s *SQLite) Get(ctx context.Context, id string) (*domain.User, error) {
+	query := s.QueryBuilder.Select(userColumns...).
+		From("user").
+		Where(sq.Eq{"id": id}).
+		Limit(1)
+
+	return s.getUser(ctx, query)
+}
+
+// GetForUpdate is Get, a single connection serializes the transactions so the user cannot change meanwhile
+func (s *SQLite) GetForUpdate(ctx context.Context, id string) (*domain.User, error) {
+	return s.Get(ctx, id)
+}
+
+func (s *SQLite) List(ctx context.Context, skip, limit uint64) ([]domain.User, error) {
+	var user domain.User
+	var users []domain.User
+
+	query := s.QueryBuilder.Select(userColumns...).
+		From("user").
+		OrderBy("id").
+		Limit(limit).
+		Offset(skip * limit)
+
+	stmt, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err := rows.Scan(
+			&user.ID,
+			&user.Document,
+			&user.Name,
+			&user.Email,
+			&user.Age,
+			&user.Password,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (s *SQLite) Update(ctx context.Context, user *domain.User) error {
+	return s.WithinTransaction(ctx, func(ctx context.Context) error {
+		now := utc(time.Now())
+
+		err := s.saveHistory(ctx, user.ID, domain.UserOperationUpdate, now)
+		if err != nil {
+			return err
+		}
+
+		query := s.QueryBuilder.Update("user").
+			Set("document", user.Document).
+			Set("name", user.Name).
+			Set("email", user.Email).
+			Set("age", user.Age).
+			Set("password", user.Password).
+			Set("updated_at", now).
+			Where(sq.Eq{"id": user.ID}).
+			Suffix("RETURNING " + columns(userColumns))
+
+		stmt, args, err := query.ToSql()
+		if err != nil {
+			return err
+		}
+
+		err = s.conn(ctx).QueryRowContext(ctx, stmt, args...).Scan(
+			&user.ID,
+			&user.Document,
+			&user.Name,
+			&user.Email,
+			&user.Age,
+			&user.Password,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		)
+		if err != nil {
+			return translateError(err)
+		}
+
+		return nil
+	})
+}
+
+func (s *SQLite) Delete(ctx context.Context, id string) error {
+	return s.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := s.saveHistory(ctx, id, domain.UserOperationDelete, utc(time.Now()))
+		if err != nil {
+			return err
+		}
+
+		query := s.QueryBuilder.Delete("user").
+			Where(sq.Eq{"id": id})
+
+		stmt, args, err := query.ToSql()
+		if err != nil {
+			return err
+		}
+
+		_, err = s.conn(ctx).ExecContext(ctx, stmt, args...)
+		if err != nil {
+			return translateError(err)
+		}
+
+		return nil
+	})
+}
+
+func (s *SQLite) getUser(ctx context.Context, query sq.SelectBuilder) (*domain.User, error) {
+	var user domain.User
+
+	stmt, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.conn(ctx).QueryRowContext(ctx, stmt, args...).Scan(
+		&user.ID,
+		&user.Document,
+		&user.Name,
+		&user.Email,
+		&user.Age,
+		&user.Password,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrDataNotFound
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// columns joins column names for a RETURNING clause
+func columns(names []string) string {
+	return strings.Join(names, ", ")
+}
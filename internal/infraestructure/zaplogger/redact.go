@@ -0,0 +1,85 @@
+package zaplogger
+
+import (
+	"errors"
+	"go-clean-arch/internal/core/redact"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactCore masks the sensitive data of every entry before the wrapped core encodes it: the fields
+// named after sensitive keys, the redact tagged fields of structs and maps, and the secrets found in
+// messages and error strings
+type redactCore struct {
+	zapcore.Core
+}
+
+// newRedactCore wraps core so nothing sensitive is written by it
+func newRedactCore(core zapcore.Core) zapcore.Core {
+	return redactCore{core}
+}
+
+func (c redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return redactCore{c.Core.With(redactFields(fields))}
+}
+
+func (c redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+
+	return checked
+}
+
+func (c redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = redact.Text(entry.Message)
+
+	return c.Core.Write(entry, redactFields(fields))
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		redacted[i] = redactField(field)
+	}
+
+	return redacted
+}
+
+func redactField(field zapcore.Field) zapcore.Field {
+	switch field.Type {
+	case zapcore.StringType:
+		if masked, ok := redact.Key(field.Key, field.String); ok {
+			return zap.Any(field.Key, masked)
+		}
+		return zap.String(field.Key, redact.Text(field.String))
+	case zapcore.ErrorType:
+		err, _ := field.Interface.(error)
+		if err == nil {
+			return field
+		}
+		//Errors keep their verbose form unless their text holds a secret
+		if text := redact.Text(err.Error()); text != err.Error() {
+			return zap.NamedError(field.Key, errors.New(text))
+		}
+		return field
+	case zapcore.ReflectType, zapcore.StringerType:
+		if masked, ok := redact.Key(field.Key, field.Interface); ok {
+			return zap.Any(field.Key, masked)
+		}
+		if field.Type == zapcore.ReflectType {
+			return zap.Any(field.Key, redact.Value(field.Interface))
+		}
+		return field
+	case zapcore.SkipType, zapcore.NamespaceType:
+		return field
+	}
+
+	//Numbers, booleans, durations and times named after a sensitive key are masked too
+	if _, ok := redact.Key(field.Key, nil); ok {
+		return zap.String(field.Key, redact.Placeholder)
+	}
+
+	return field
+}
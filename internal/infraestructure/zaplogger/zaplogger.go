@@ -0,0 +1,52 @@
+// Package zaplogger builds the zap logger every layer of the application logs with.
+package zaplogger
+
+import (
+	"go-clean-arch/internal/infraestructure/config"
+	"log/slog"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/exp/zapslog"
+	"go.uber.org/zap/zapcore"
+)
+
+// New builds the logger described by config, its level can be changed while running through the
+// returned one. Sensitive data is masked from everything it writes, see redactCore. The default slog
+// logger writes through it, so libraries logging with slog end up in the same output with the same format.
+func New(config *config.Log) (*zap.Logger, zap.AtomicLevel, error) {
+	var zapConfig zap.Config
+
+	switch config.Env {
+	case "development":
+		zapConfig = zap.NewDevelopmentConfig()
+	default:
+		zapConfig = zap.NewProductionConfig()
+	}
+
+	if config.Format != "" {
+		zapConfig.Encoding = config.Format
+	}
+	zapConfig.Level = zap.NewAtomicLevelAt(Level(config))
+
+	logger, err := zapConfig.Build(zap.WrapCore(newRedactCore))
+	if err != nil {
+		return nil, zapConfig.Level, err
+	}
+
+	slog.SetDefault(slog.New(zapslog.NewHandler(logger.Core())))
+
+	return logger, zapConfig.Level, nil
+}
+
+// Level returns the level of LOG_LEVEL, debug in development and info otherwise when it is not set
+func Level(config *config.Log) zapcore.Level {
+	if config.Level == "" {
+		if config.Env == "development" {
+			return zapcore.DebugLevel
+		}
+		return zapcore.InfoLevel
+	}
+
+	level, _ := zapcore.ParseLevel(config.Level)
+	return level
+}
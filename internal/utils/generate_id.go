@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -14,3 +15,10 @@ func BuildIdempotencyKey(user *domain.User) {
 
 	user.ID = hex.EncodeToString(sum[:])
 }
+
+func GenerateID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,20 @@
+CREATE TABLE public.audit_log (
+    id VARCHAR NOT NULL,
+    actor VARCHAR NOT NULL,
+    action VARCHAR NOT NULL,
+    target_type VARCHAR NOT NULL,
+    target_id VARCHAR NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}',
+    request_id VARCHAR NOT NULL DEFAULT '',
+    client_ip VARCHAR NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
+
+ALTER TABLE public.audit_log
+    ADD CONSTRAINT audit_log_pk PRIMARY KEY (id);
+
+CREATE INDEX audit_log_target_idx ON public.audit_log (target_type, target_id, created_at);
+
+CREATE INDEX audit_log_actor_idx ON public.audit_log (actor, created_at);
+
+CREATE INDEX audit_log_created_at_idx ON public.audit_log (created_at);
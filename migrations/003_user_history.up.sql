@@ -0,0 +1,19 @@
+CREATE TABLE public.user_history (
+    history_id BIGSERIAL NOT NULL,
+    id VARCHAR NOT NULL,
+    document VARCHAR NOT NULL,
+    name VARCHAR NOT NULL,
+    email VARCHAR NOT NULL,
+    age INTEGER NOT NULL,
+    password VARCHAR NOT NULL,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    operation VARCHAR NOT NULL,
+    valid_from TIMESTAMP NOT NULL,
+    valid_to TIMESTAMP NOT NULL
+);
+
+ALTER TABLE public.user_history
+    ADD CONSTRAINT user_history_pk PRIMARY KEY (history_id);
+
+CREATE INDEX user_history_id_validity_idx ON public.user_history (id, valid_from, valid_to);
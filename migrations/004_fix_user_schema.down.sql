@@ -0,0 +1,59 @@
+DROP INDEX IF EXISTS public.audit_log_created_at_idx;
+DROP INDEX IF EXISTS public.audit_log_action_idx;
+DROP INDEX IF EXISTS public.audit_log_actor_idx;
+DROP INDEX IF EXISTS public.audit_log_target_id_idx;
+
+CREATE INDEX audit_log_target_idx ON public.audit_log (target_type, target_id, created_at);
+
+CREATE INDEX audit_log_actor_idx ON public.audit_log (actor, created_at);
+
+CREATE INDEX audit_log_created_at_idx ON public.audit_log (created_at);
+
+ALTER TABLE public.audit_log
+    ALTER COLUMN id TYPE VARCHAR,
+    ALTER COLUMN actor TYPE VARCHAR,
+    ALTER COLUMN action TYPE VARCHAR,
+    ALTER COLUMN target_type TYPE VARCHAR,
+    ALTER COLUMN target_id TYPE VARCHAR,
+    ALTER COLUMN request_id TYPE VARCHAR,
+    ALTER COLUMN client_ip TYPE VARCHAR,
+    ALTER COLUMN created_at DROP DEFAULT,
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+
+DROP INDEX IF EXISTS public.user_history_id_valid_to_idx;
+
+ALTER TABLE public.user_history
+    ALTER COLUMN id TYPE VARCHAR,
+    ALTER COLUMN document TYPE VARCHAR,
+    ALTER COLUMN name TYPE VARCHAR,
+    ALTER COLUMN email TYPE VARCHAR,
+    ALTER COLUMN password TYPE VARCHAR,
+    ALTER COLUMN operation TYPE VARCHAR,
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMP USING updated_at AT TIME ZONE 'UTC',
+    ALTER COLUMN valid_from TYPE TIMESTAMP USING valid_from AT TIME ZONE 'UTC',
+    ALTER COLUMN valid_to TYPE TIMESTAMP USING valid_to AT TIME ZONE 'UTC';
+
+DROP INDEX IF EXISTS public.user_unique_email;
+
+ALTER TABLE public."user"
+    ADD CONSTRAINT user_unique_email UNIQUE (email);
+
+ALTER TABLE public."user"
+    DROP CONSTRAINT user_age_check;
+
+ALTER TABLE public."user"
+    ALTER COLUMN id TYPE VARCHAR,
+    ALTER COLUMN document TYPE VARCHAR,
+    ALTER COLUMN name TYPE VARCHAR,
+    ALTER COLUMN email TYPE VARCHAR,
+    ALTER COLUMN password TYPE VARCHAR,
+    ALTER COLUMN created_at DROP NOT NULL,
+    ALTER COLUMN created_at DROP DEFAULT,
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at DROP NOT NULL,
+    ALTER COLUMN updated_at DROP DEFAULT,
+    ALTER COLUMN updated_at TYPE TIMESTAMP USING updated_at AT TIME ZONE 'UTC';
+
+ALTER TABLE public."user"
+    ADD CONSTRAINT user_unique_password UNIQUE (password);
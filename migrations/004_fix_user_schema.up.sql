@@ -0,0 +1,82 @@
+-- A unique password leaks on conflict that another user has the same password
+ALTER TABLE public."user"
+    DROP CONSTRAINT user_unique_password;
+
+UPDATE public."user" SET created_at = COALESCE(updated_at, now()) WHERE created_at IS NULL;
+UPDATE public."user" SET updated_at = created_at WHERE updated_at IS NULL;
+
+ALTER TABLE public."user"
+    ALTER COLUMN id TYPE VARCHAR(64),
+    ALTER COLUMN document TYPE VARCHAR(20),
+    ALTER COLUMN name TYPE VARCHAR(255),
+    ALTER COLUMN email TYPE VARCHAR(254),
+    ALTER COLUMN password TYPE VARCHAR(72),
+    ALTER COLUMN created_at TYPE TIMESTAMP WITH TIME ZONE USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at SET DEFAULT now(),
+    ALTER COLUMN created_at SET NOT NULL,
+    ALTER COLUMN updated_at TYPE TIMESTAMP WITH TIME ZONE USING updated_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at SET DEFAULT now(),
+    ALTER COLUMN updated_at SET NOT NULL;
+
+ALTER TABLE public."user"
+    ADD CONSTRAINT user_age_check CHECK (age BETWEEN 0 AND 150);
+
+-- Emails are unique regardless of case, the index keeps the name of the constraint it replaces.
+-- Users whose emails only differ in case must be merged by hand first, which one to keep is not ours to guess.
+DO $$
+DECLARE
+    duplicates BIGINT;
+BEGIN
+    SELECT count(*) INTO duplicates
+    FROM (SELECT 1 FROM public."user" GROUP BY lower(email) HAVING count(*) > 1) AS d;
+
+    IF duplicates > 0 THEN
+        RAISE EXCEPTION '% emails are used by several users in different cases', duplicates
+            USING HINT = 'Find them with SELECT lower(email) FROM public."user" GROUP BY 1 HAVING count(*) > 1 and merge those users before migrating';
+    END IF;
+END $$;
+
+ALTER TABLE public."user"
+    DROP CONSTRAINT user_unique_email;
+
+CREATE UNIQUE INDEX user_unique_email ON public."user" (lower(email));
+
+ALTER TABLE public.user_history
+    ALTER COLUMN id TYPE VARCHAR(64),
+    ALTER COLUMN document TYPE VARCHAR(20),
+    ALTER COLUMN name TYPE VARCHAR(255),
+    ALTER COLUMN email TYPE VARCHAR(254),
+    ALTER COLUMN password TYPE VARCHAR(72),
+    ALTER COLUMN operation TYPE VARCHAR(16),
+    ALTER COLUMN created_at TYPE TIMESTAMP WITH TIME ZONE USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMP WITH TIME ZONE USING updated_at AT TIME ZONE 'UTC',
+    ALTER COLUMN valid_from TYPE TIMESTAMP WITH TIME ZONE USING valid_from AT TIME ZONE 'UTC',
+    ALTER COLUMN valid_to TYPE TIMESTAMP WITH TIME ZONE USING valid_to AT TIME ZONE 'UTC';
+
+-- Serves the history listing, most recent version first
+CREATE INDEX user_history_id_valid_to_idx ON public.user_history (id, valid_to DESC, history_id DESC);
+
+ALTER TABLE public.audit_log
+    ALTER COLUMN id TYPE VARCHAR(32),
+    ALTER COLUMN actor TYPE VARCHAR(255),
+    ALTER COLUMN action TYPE VARCHAR(32),
+    ALTER COLUMN target_type TYPE VARCHAR(32),
+    ALTER COLUMN target_id TYPE VARCHAR(64),
+    ALTER COLUMN request_id TYPE VARCHAR(128),
+    ALTER COLUMN client_ip TYPE VARCHAR(45),
+    ALTER COLUMN created_at TYPE TIMESTAMP WITH TIME ZONE USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at SET DEFAULT now();
+
+-- Serve the audit listing, filtered by any of actor, action and target ID and sorted by created_at DESC, id.
+-- The target index led with target_type, which the listing never filters on.
+DROP INDEX public.audit_log_target_idx;
+DROP INDEX public.audit_log_actor_idx;
+DROP INDEX public.audit_log_created_at_idx;
+
+CREATE INDEX audit_log_target_id_idx ON public.audit_log (target_id, created_at DESC, id);
+
+CREATE INDEX audit_log_actor_idx ON public.audit_log (actor, created_at DESC, id);
+
+CREATE INDEX audit_log_action_idx ON public.audit_log (action, created_at DESC, id);
+
+CREATE INDEX audit_log_created_at_idx ON public.audit_log (created_at DESC, id);
@@ -0,0 +1,2 @@
+ALTER TABLE public.audit_log
+    DROP COLUMN IF EXISTS actor_verified;
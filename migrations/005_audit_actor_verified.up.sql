@@ -0,0 +1,3 @@
+-- The actors recorded so far were claimed through the X-User-ID header, never authenticated
+ALTER TABLE public.audit_log
+    ADD COLUMN actor_verified BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,9 @@
+// Package migrations embeds the postgres migrations so the binary carries its own schema.
+package migrations
+
+import "embed"
+
+// FS holds every migration file of this directory
+//
+//go:embed *.sql
+var FS embed.FS